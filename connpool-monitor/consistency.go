@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// maxPendingWrites bounds the writes waiting to be checked when reads can't
+// keep up with writes
+const maxPendingWrites = 100
+
+// pendingWrite is a row written through the proxy that a later read should see
+type pendingWrite struct {
+	ID      int64
+	Written time.Time
+}
+
+// ConsistencyChecker queues the ids inserted through one pool so that
+// --consistency-check reads on the same pool can verify read-after-write
+type ConsistencyChecker struct {
+	mu      sync.Mutex
+	pending []pendingWrite
+}
+
+// Record queues a written row for checking, dropping the oldest if full
+func (c *ConsistencyChecker) Record(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = append(c.pending, pendingWrite{ID: id, Written: time.Now()})
+	if len(c.pending) > maxPendingWrites {
+		c.pending = c.pending[len(c.pending)-maxPendingWrites:]
+	}
+}
+
+// next takes the oldest queued write, if any
+func (c *ConsistencyChecker) next() (pendingWrite, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) == 0 {
+		return pendingWrite{}, false
+	}
+	w := c.pending[0]
+	c.pending = c.pending[1:]
+	return w, true
+}
+
+// requeue puts a write that isn't visible yet back at the front of the queue
+func (c *ConsistencyChecker) requeue(w pendingWrite) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = append([]pendingWrite{w}, c.pending...)
+}
+
+// checkReadAfterWrite looks for the oldest queued write using the read's
+// connection. A row still missing after --consistency-grace counts as a stale
+// read against the node that served the read.
+func checkReadAfterWrite(ctx context.Context, c *ConsistencyChecker, conn *sql.Conn, readNode string) {
+	w, ok := c.next()
+	if !ok {
+		return
+	}
+
+	var found int
+	err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM connpool_test WHERE id = ?", w.ID).Scan(&found)
+	if err != nil {
+		// Not a consistency verdict; retry the write on a later read
+		c.requeue(w)
+		return
+	}
+
+	if found == 0 && time.Since(w.Written) < cfg.ConsistencyGrace {
+		c.requeue(w)
+		return
+	}
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	stats.ConsistencyChecks++
+	if found == 0 {
+		stats.StaleReads++
+		stats.LastStaleRead = time.Now()
+		stats.LastStaleReadNode = readNode
+	}
+}
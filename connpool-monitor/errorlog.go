@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// errorLogRecord is one --log-file line. Unlike the terminal table it keeps
+// the full error message.
+type errorLogRecord struct {
+	Event     string    `json:"event"` // always "error"
+	Timestamp time.Time `json:"timestamp"`
+	Operation string    `json:"operation"`
+	Node      string    `json:"node,omitempty"`
+	Category  string    `json:"category"`
+	Error     string    `json:"error"`
+}
+
+// ErrorLog appends every connection error to a file as JSON lines
+type ErrorLog struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// errorLog is nil unless --log-file is set
+var errorLog *ErrorLog
+
+// openErrorLog opens path for appending, creating it if needed
+func openErrorLog(path string) (*ErrorLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorLog{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Write appends one error. Each record is written with a single write call
+// so a tail -f never sees half a line.
+func (l *ErrorLog) Write(e ConnectionError) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.encode(errorLogRecord{
+		Event:     "error",
+		Timestamp: e.Timestamp,
+		Operation: e.Operation,
+		Node:      e.Node,
+		Category:  e.Category,
+		Error:     e.Error,
+	})
+}
+
+// encode writes one JSON line. Callers must hold l.mu.
+func (l *ErrorLog) encode(v interface{}) {
+	if err := l.enc.Encode(v); err != nil {
+		color.Red("Failed to write --log-file: %v", err)
+	}
+}
+
+// queryTraceRecord is one --verbose trace line in --log-file
+type queryTraceRecord struct {
+	Event     string    `json:"event"` // always "query"
+	Timestamp time.Time `json:"timestamp"`
+	Endpoint  string    `json:"endpoint"`
+	Operation string    `json:"operation"`
+	ConnID    int64     `json:"conn_id,omitempty"`
+	Node      string    `json:"node,omitempty"`
+	AcquireMs float64   `json:"acquire_ms"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// QueryTrace is the lifecycle of one workload operation: the pooled
+// connection it got, the backend that served it, and how long each step took
+type QueryTrace struct {
+	Endpoint  string
+	Operation string
+	ConnID    int64
+	Node      string
+	Acquire   time.Duration
+	Latency   time.Duration
+	Err       error
+}
+
+// traceQuery records t as a JSON line in --log-file when --verbose is set
+func traceQuery(t QueryTrace) {
+	if !cfg.Verbose || errorLog == nil {
+		return
+	}
+
+	errStr := ""
+	if t.Err != nil {
+		errStr = t.Err.Error()
+	}
+
+	errorLog.mu.Lock()
+	defer errorLog.mu.Unlock()
+	errorLog.encode(queryTraceRecord{
+		Event:     "query",
+		Timestamp: time.Now(),
+		Endpoint:  t.Endpoint,
+		Operation: t.Operation,
+		ConnID:    t.ConnID,
+		Node:      t.Node,
+		AcquireMs: float64(t.Acquire) / float64(time.Millisecond),
+		LatencyMs: float64(t.Latency) / float64(time.Millisecond),
+		Error:     errStr,
+	})
+}
+
+// Close closes the log file
+func (l *ErrorLog) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// ErrorGroup counts every occurrence of one normalized error message, so the
+// digest covers the whole run rather than just the last 100 errors
+type ErrorGroup struct {
+	Message   string
+	Count     int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+var (
+	bracketedAddrPattern = regexp.MustCompile(`\[[0-9a-fA-F:.%]+\](:\d+)?`)
+	ipv4AddrPattern      = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`)
+	connIDPattern        = regexp.MustCompile(`(?i)\b(connection|conn|thread)( id)?:? ?\d+`)
+	hexPattern           = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`)
+)
+
+// normalizeErrorMessage strips the parts of an error that change between
+// otherwise identical failures: addresses, connection/thread ids and pointers
+func normalizeErrorMessage(msg string) string {
+	msg = bracketedAddrPattern.ReplaceAllString(msg, "<addr>")
+	msg = ipv4AddrPattern.ReplaceAllString(msg, "<addr>")
+	msg = connIDPattern.ReplaceAllString(msg, "$1$2 <id>")
+	msg = hexPattern.ReplaceAllString(msg, "<hex>")
+	return strings.TrimSpace(msg)
+}
+
+// groupError adds one error occurrence to the run-wide digest. Callers must
+// hold stats.mu.
+func groupError(msg string, at time.Time) {
+	if stats.ErrorGroups == nil {
+		stats.ErrorGroups = make(map[string]*ErrorGroup)
+	}
+	key := normalizeErrorMessage(msg)
+	g, ok := stats.ErrorGroups[key]
+	if !ok {
+		g = &ErrorGroup{Message: key, FirstSeen: at}
+		stats.ErrorGroups[key] = g
+	}
+	g.Count++
+	g.LastSeen = at
+}
+
+// errorSummary returns the error digest, most frequent first
+func errorSummary() []ErrorGroup {
+	stats.mu.RLock()
+	groups := make([]ErrorGroup, 0, len(stats.ErrorGroups))
+	for _, g := range stats.ErrorGroups {
+		groups = append(groups, *g)
+	}
+	stats.mu.RUnlock()
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].FirstSeen.Before(groups[j].FirstSeen)
+	})
+	return groups
+}
+
+// printErrorSummary prints the distinct errors seen during the run, e.g.
+// "connection refused x 143, from 10:02:11 to 10:02:47"
+func printErrorSummary() {
+	groups := errorSummary()
+
+	bold := color.New(color.Bold)
+	bold.Println("[ERROR SUMMARY]")
+	fmt.Println(strings.Repeat("-", 79))
+
+	if len(groups) == 0 {
+		color.Green("  No errors during this run")
+		fmt.Println()
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Count", "First Seen", "Last Seen", "Error"})
+	table.SetBorder(false)
+	table.SetColumnSeparator("|")
+
+	for _, g := range groups {
+		msg := g.Message
+		if len(msg) > 60 {
+			msg = msg[:57] + "..."
+		}
+		table.Append([]string{
+			color.RedString("%d", g.Count),
+			g.FirstSeen.Format("15:04:05"),
+			g.LastSeen.Format("15:04:05"),
+			msg,
+		})
+	}
+	table.Render()
+	fmt.Println()
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeResult is the scripted answer to one statement
+type fakeResult struct {
+	columns      []string
+	rows         [][]driver.Value
+	lastInsertID int64
+	err          error
+}
+
+// fakeHandler answers every statement sent to a fakeDB. connID identifies
+// the physical connection the statement arrived on.
+type fakeHandler func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult
+
+// fakeConnector is a database/sql driver with no server behind it, so the
+// workload and fetch functions can run against scripted results
+type fakeConnector struct {
+	handler      fakeHandler
+	connectDelay time.Duration
+
+	mu      sync.Mutex
+	queries []string
+	nextID  int64
+}
+
+// newFakeDB opens a pool whose statements are answered by handler
+func newFakeDB(t *testing.T, handler fakeHandler) (*sql.DB, *fakeConnector) {
+	t.Helper()
+	c := &fakeConnector{handler: handler}
+	db := sql.OpenDB(c)
+	t.Cleanup(func() { db.Close() })
+	return db, c
+}
+
+// scalar is a one-row, one-column result
+func scalar(v driver.Value) fakeResult {
+	return fakeResult{columns: []string{"v"}, rows: [][]driver.Value{{v}}}
+}
+
+// Queries returns every statement received so far
+func (c *fakeConnector) Queries() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.queries...)
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.connectDelay > 0 {
+		select {
+		case <-time.After(c.connectDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &fakeConn{c: c, id: atomic.AddInt64(&c.nextID, 1)}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver: use sql.OpenDB")
+}
+
+type fakeConn struct {
+	c  *fakeConnector
+	id int64
+}
+
+func (c *fakeConn) run(ctx context.Context, query string, args []driver.NamedValue) fakeResult {
+	c.c.mu.Lock()
+	c.c.queries = append(c.c.queries, query)
+	c.c.mu.Unlock()
+
+	r := c.c.handler(ctx, c.id, query, args)
+	if r.err == nil && ctx.Err() != nil {
+		r.err = ctx.Err()
+	}
+	return r
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r := c.run(ctx, query, args)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &fakeRows{columns: r.columns, rows: r.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	r := c.run(ctx, query, args)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return fakeExecResult{lastInsertID: r.lastInsertID}, nil
+}
+
+type fakeExecResult struct {
+	lastInsertID int64
+}
+
+func (r fakeExecResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r fakeExecResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver: prepared statements not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// JSONReport is one --output json line, written every refresh in place of
+// the dashboard so a failover test can be recorded and scripted against
+type JSONReport struct {
+	Time         time.Time     `json:"time"`
+	Pool         JSONPoolStats `json:"pool"`
+	TotalReads   int64         `json:"totalReads"`
+	TotalWrites  int64         `json:"totalWrites"`
+	FailedReads  int64         `json:"failedReads"`
+	FailedWrites int64         `json:"failedWrites"`
+
+	HAProxyBackends     []HAProxyBackend   `json:"haproxyBackends,omitempty"`
+	HAProxyError        string             `json:"haproxyError,omitempty"`
+	ProxySQLServers     []ProxySQLServer   `json:"proxysqlServers,omitempty"`
+	ProxySQLServerError string             `json:"proxysqlServerError,omitempty"`
+	ProxySQLPool        []ProxySQLConnPool `json:"proxysqlPool,omitempty"`
+	ProxySQLPoolError   string             `json:"proxysqlPoolError,omitempty"`
+	PXCNodes            []PXCNodeStatus    `json:"pxcNodes,omitempty"`
+
+	// Set when no backend of a monitored proxy can take connections, the
+	// same condition that shows the ALL BACKENDS DOWN banner
+	AllBackendsDown bool `json:"allBackendsDown"`
+}
+
+// JSONPoolStats is the combined pool state of every proxy endpoint
+type JSONPoolStats struct {
+	Open           int     `json:"open"`
+	InUse          int     `json:"inUse"`
+	Idle           int     `json:"idle"`
+	WaitCount      int64   `json:"waitCount"`
+	WaitDurationMs float64 `json:"waitDurationMs"`
+}
+
+// runJSONOutput writes a JSONReport to stdout every refresh until ctx is
+// cancelled
+func runJSONOutput(ctx context.Context, pools []*ProxyPool) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			poolHistory.Sample(pools)
+			if err := enc.Encode(buildJSONReport(ctx, pools)); err != nil {
+				color.Red("Failed to write JSON report: %v", err)
+			}
+		}
+	}
+}
+
+// buildJSONReport fetches the proxy and PXC state the dashboard would show
+func buildJSONReport(ctx context.Context, pools []*ProxyPool) JSONReport {
+	refreshSnapshot(ctx)
+	return snapshotJSONReport(pools)
+}
+
+// snapshotJSONReport builds a JSONReport from the pools, the stats and the
+// last fetched snapshot without querying anything
+func snapshotJSONReport(pools []*ProxyPool) JSONReport {
+	dbStats := combinedDBStats(pools)
+	r := JSONReport{
+		Time: time.Now(),
+		Pool: JSONPoolStats{
+			Open:           dbStats.OpenConnections,
+			InUse:          dbStats.InUse,
+			Idle:           dbStats.Idle,
+			WaitCount:      dbStats.WaitCount,
+			WaitDurationMs: float64(dbStats.WaitDuration) / float64(time.Millisecond),
+		},
+	}
+
+	stats.mu.RLock()
+	r.TotalReads = stats.TotalReads
+	r.TotalWrites = stats.TotalWrites
+	r.FailedReads = stats.FailedReads
+	r.FailedWrites = stats.FailedWrites
+	stats.mu.RUnlock()
+
+	snapshot.mu.RLock()
+	r.HAProxyBackends, r.HAProxyError = snapshot.HAProxyBackends, snapshot.HAProxyError
+	r.ProxySQLServers, r.ProxySQLServerError = snapshot.ProxySQLServers, snapshot.ProxySQLServerError
+	r.ProxySQLPool, r.ProxySQLPoolError = snapshot.ProxySQLPool, snapshot.ProxySQLPoolError
+	r.PXCNodes = snapshot.PXCNodes
+	snapshot.mu.RUnlock()
+
+	r.AllBackendsDown = allHAProxyBackendsDown(r.HAProxyBackends) || allProxySQLServersDown(r.ProxySQLPool)
+	return r
+}
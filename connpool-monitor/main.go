@@ -2,10 +2,18 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"sort"
@@ -17,7 +25,7 @@ import (
 	"time"
 
 	"github.com/fatih/color"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -27,6 +35,12 @@ type Config struct {
 	// Proxy connection (HAProxy or ProxySQL)
 	ProxyHost     string
 	ProxyPort     int
+	ProxyHosts    []string
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration
+	DSNParams     string
+	TLSMode       string
+	TLSCA         string
 	ProxyUser     string
 	ProxyPassword string
 	Database      string
@@ -44,8 +58,16 @@ type Config struct {
 
 	// PXC nodes (for direct monitoring)
 	PXCNodes    []string
+	PXCPort     int
 	PXCUser     string
 	PXCPassword string
+	ProbeNodes  bool
+
+	// Consecutive refreshes with nonzero flow control before a node is flagged
+	FCSustainedRefreshes int
+
+	// Percent of max_connections at which a node's connection count turns red
+	MaxConnectionsWarn int
 
 	// Pool settings (HikariCP-like)
 	PoolSize           int
@@ -55,14 +77,145 @@ type Config struct {
 	ConnectionTimeout  time.Duration
 	ValidationInterval time.Duration
 
+	// Reconnect backoff after failed connection acquisition
+	ReconnectBackoff time.Duration
+	ReconnectMax     time.Duration
+
+	// Query that identifies the backend serving a connection
+	BackendIDQuery string
+
 	// Workload settings
 	ReadQPS       int
 	WriteQPS      int
 	QueryInterval time.Duration
 
+	// Read-after-write verification
+	ConsistencyCheck bool
+	ConsistencyGrace time.Duration
+
 	// Mode
 	UseProxySQL bool
+	Both        bool
 	Verbose     bool
+
+	// Bound on each HAProxy, ProxySQL admin and PXC node fetch per refresh
+	StatsTimeout time.Duration
+
+	// HTML status page
+	HTTPAddr string
+
+	// JSON lines error log, optionally without the terminal dashboard
+	LogFile string
+	Quiet   bool
+
+	// Dashboard format: "table" for the terminal, "json" for a report per refresh
+	Output string
+
+	// Pool exhaustion stress mode
+	StressExhaust bool
+	StressRamp    time.Duration
+}
+
+// ProxyPool is a connection pool opened against a single proxy endpoint
+type ProxyPool struct {
+	Addr    string
+	DB      *sql.DB
+	Backoff *ReconnectBackoff
+
+	// Writes awaiting a read-after-write check (--consistency-check)
+	Consistency *ConsistencyChecker
+
+	// When the --min-idle warmup finished, for the idle count check
+	WarmedUp time.Time
+
+	// Cumulative driver counters captured at the last stats reset
+	mu       sync.Mutex
+	baseline sql.DBStats
+}
+
+// Stats returns the pool's driver statistics with the cumulative wait and
+// close counters measured from the last stats reset
+func (p *ProxyPool) Stats() sql.DBStats {
+	s := p.DB.Stats()
+
+	p.mu.Lock()
+	b := p.baseline
+	p.mu.Unlock()
+
+	s.WaitCount -= b.WaitCount
+	s.WaitDuration -= b.WaitDuration
+	s.MaxIdleClosed -= b.MaxIdleClosed
+	s.MaxIdleTimeClosed -= b.MaxIdleTimeClosed
+	s.MaxLifetimeClosed -= b.MaxLifetimeClosed
+	return s
+}
+
+// ResetBaseline makes Stats count waits and closes from now on
+func (p *ProxyPool) ResetBaseline() {
+	s := p.DB.Stats()
+
+	p.mu.Lock()
+	p.baseline = s
+	p.mu.Unlock()
+}
+
+// ReconnectBackoff pauses a pool's workload after failed connection
+// acquisitions so a recovering proxy isn't hammered with retries
+type ReconnectBackoff struct {
+	mu       sync.Mutex
+	failures int
+	current  time.Duration
+	until    time.Time
+}
+
+// Fail records a failed acquisition and schedules the next retry using
+// exponential backoff with jitter, capped at --reconnect-max
+func (b *ReconnectBackoff) Fail() {
+	if cfg.ReconnectBackoff <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	d := cfg.ReconnectBackoff
+	for i := 1; i < b.failures && d < cfg.ReconnectMax; i++ {
+		d *= 2
+	}
+	if d > cfg.ReconnectMax {
+		d = cfg.ReconnectMax
+	}
+
+	// Jitter across the upper half so multiple pools don't retry in lockstep
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+
+	b.current = d
+	b.until = time.Now().Add(d)
+}
+
+// Succeed clears the backoff once a connection is acquired again
+func (b *ReconnectBackoff) Succeed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.current = 0
+	b.until = time.Time{}
+}
+
+// Paused reports whether the workload should skip this tick
+func (b *ReconnectBackoff) Paused() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return time.Now().Before(b.until)
+}
+
+// State returns the consecutive failure count and current backoff delay
+func (b *ReconnectBackoff) State() (int, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures, b.current
 }
 
 // ConnectionStats tracks connection-level statistics
@@ -84,85 +237,191 @@ type ConnectionStats struct {
 	LastWriteLatency time.Duration
 	AvgReadLatency   time.Duration
 	AvgWriteLatency  time.Duration
+	MinReadLatency   time.Duration
+	MaxReadLatency   time.Duration
+	MinWriteLatency  time.Duration
+	MaxWriteLatency  time.Duration
+
+	// Time spent waiting for db.Conn to hand out a connection, kept
+	// separate from query latency so pool contention stands out
+	TotalAcquires      int64
+	LastAcquireLatency time.Duration
+	AvgAcquireLatency  time.Duration
+	MaxAcquireLatency  time.Duration
 
 	ConnectionErrors []ConnectionError
+	ErrorCategories  map[string]int64
 	LastBackendNode  string
+
+	// Every error this run grouped by normalized message, unlike the
+	// ConnectionErrors ring which only keeps the last 100
+	ErrorGroups map[string]*ErrorGroup
+
+	// Successful reads/writes per backend node, keyed by @@server_id
+	Routing map[int64]*NodeRouting
+
+	// Read-after-write checks (--consistency-check)
+	ConsistencyChecks int64
+	StaleReads        int64
+	LastStaleRead     time.Time
+	LastStaleReadNode string
+
+	// When the counters were last zeroed with SIGUSR1
+	ResetAt time.Time
+}
+
+// NodeRouting counts where successful reads and writes actually landed
+type NodeRouting struct {
+	ServerID int64
+	Node     string // --backend-id-query result last seen for this server
+	Reads    int64
+	Writes   int64
 }
 
+// unknownServerID is the routing key for operations whose backend couldn't
+// be identified; MySQL server ids are unsigned so it never collides
+const unknownServerID = -1
+
 type ConnectionError struct {
 	Timestamp time.Time
 	Operation string
 	Error     string
 	Node      string
+	Category  string
 }
 
+// Error categories reported in the error breakdown
+const (
+	ErrCategoryTooManyConnections = "Too many connections (1040)"
+	ErrCategoryLockWaitTimeout    = "Lock wait timeout (1205)"
+	ErrCategoryDeadlock           = "Deadlock (1213)"
+	ErrCategoryGoneAway           = "Server has gone away (2006)"
+	ErrCategoryLostConnection     = "Lost connection (2013)"
+	ErrCategoryRefused            = "Connection refused"
+	ErrCategoryTimeout            = "Timeout"
+	ErrCategoryOther              = "Other"
+)
+
 // HAProxyBackend represents a backend server in HAProxy
 type HAProxyBackend struct {
-	Name        string
-	Status      string
-	Addr        string
-	CurrentConn int
-	MaxConn     int
-	Sessions    int
-	CheckStatus string
-	LastChange  string
-	Downtime    string
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Addr        string `json:"addr"`
+	CurrentConn int    `json:"currentConn"`
+	MaxConn     int    `json:"maxConn"`
+	Sessions    int    `json:"sessions"`
+	CheckStatus string `json:"checkStatus"`
+	LastChange  string `json:"lastChange"`
+	Downtime    string `json:"downtime"`
+
+	// Health check detail: HTTP/MySQL response code, check duration in ms,
+	// and the free-form last_chk text explaining the result
+	CheckCode     string `json:"checkCode"`
+	CheckDuration string `json:"checkDuration"`
+	LastCheck     string `json:"lastCheck"`
 }
 
 // ProxySQLServer represents a MySQL server in ProxySQL
 type ProxySQLServer struct {
-	HostgroupID  int
-	Hostname     string
-	Port         int
-	Status       string
-	Weight       int
-	Compression  int
-	MaxConns     int
-	UsedConns    int
-	FreeConns    int
-	MaxLatencyMs int
-	Comment      string
+	HostgroupID  int    `json:"hostgroupId"`
+	Hostname     string `json:"hostname"`
+	Port         int    `json:"port"`
+	Status       string `json:"status"`
+	Weight       int    `json:"weight"`
+	Compression  int    `json:"compression"`
+	MaxConns     int    `json:"maxConns"`
+	UsedConns    int    `json:"usedConns"`
+	FreeConns    int    `json:"freeConns"`
+	MaxLatencyMs int    `json:"maxLatencyMs"`
+	Comment      string `json:"comment"`
+
+	// How runtime_mysql_servers differs from this mysql_servers row, empty
+	// when the config has been loaded to runtime
+	Drift string `json:"drift,omitempty"`
 }
 
 // ProxySQLConnPool represents connection pool stats from ProxySQL
 type ProxySQLConnPool struct {
-	HostgroupID   int
-	SrvHost       string
-	SrvPort       int
-	Status        string
-	ConnUsed      int
-	ConnFree      int
-	ConnOK        int
-	ConnErr       int
-	Queries       int64
-	BytesDataSent int64
-	BytesDataRecv int64
-	LatencyUs     int64
+	HostgroupID   int    `json:"hostgroupId"`
+	SrvHost       string `json:"srvHost"`
+	SrvPort       int    `json:"srvPort"`
+	Status        string `json:"status"`
+	ConnUsed      int    `json:"connUsed"`
+	ConnFree      int    `json:"connFree"`
+	ConnOK        int    `json:"connOk"`
+	ConnErr       int    `json:"connErr"`
+	Queries       int64  `json:"queries"`
+	BytesDataSent int64  `json:"bytesDataSent"`
+	BytesDataRecv int64  `json:"bytesDataRecv"`
+	LatencyUs     int64  `json:"latencyUs"`
+}
+
+// ProxySQLQueryRule represents a routing rule from mysql_query_rules
+type ProxySQLQueryRule struct {
+	RuleID               int
+	Active               int
+	MatchPattern         string
+	DestinationHostgroup sql.NullInt64
+	Apply                int
+}
+
+// ProxySQLGlobalStats holds key counters from stats_mysql_global
+type ProxySQLGlobalStats struct {
+	Questions                  int64
+	SlowQueries                int64
+	ActiveTransactions         int64
+	ClientConnectionsConnected int64
+	ClientConnectionsAborted   int64
+	ServerConnectionsConnected int64
+	ServerConnectionsAborted   int64
+	ConnPoolMemoryBytes        int64
+	QueryCacheMemoryBytes      int64
 }
 
 // PXCNodeStatus represents wsrep status of a PXC node
 type PXCNodeStatus struct {
-	NodeName       string
-	Address        string
-	ClusterStatus  string
-	ClusterSize    int
-	LocalState     string
-	LocalStateUUID string
-	ReadyStatus    string
-	Connected      string
-	DesyncCount    int
-	RecvQueue      int
-	SendQueue      int
-	FlowControl    string
-	Connections    int
+	NodeName       string        `json:"nodeName"`
+	Address        string        `json:"address"`
+	ClusterStatus  string        `json:"clusterStatus"`
+	ClusterSize    int           `json:"clusterSize"`
+	LocalState     string        `json:"localState"`
+	LocalStateUUID string        `json:"localStateUuid"`
+	ReadyStatus    string        `json:"readyStatus"`
+	Connected      string        `json:"connected"`
+	DesyncCount    int           `json:"desyncCount"`
+	RecvQueue      int           `json:"recvQueue"`
+	SendQueue      int           `json:"sendQueue"`
+	FlowControl    string        `json:"flowControl"`
+	Connections    int           `json:"connections"`
+	MaxConnections int           `json:"maxConnections"`
+	ProbeLatency   time.Duration `json:"probeLatencyNs"`
+	ProbeError     string        `json:"probeError,omitempty"`
+
+	// State transfer progress
+	LastCommitted    int64   `json:"lastCommitted"`
+	CertDepsDistance float64 `json:"certDepsDistance"`
 }
 
 var (
 	cfg   Config
 	stats ConnectionStats
+
+	// Previous stats_mysql_global sample, used to show per-refresh deltas
+	lastProxySQLGlobal *ProxySQLGlobalStats
+
+	// Status transitions per ProxySQL backend
+	proxySQLStatusHistory ProxySQLStatusTracker
 )
 
 func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// newRootCmd builds the command and binds its flags to cfg
+func newRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "connpool-monitor",
 		Short: "Monitor HikariCP-like connection pool behavior through HAProxy/ProxySQL to PXC",
@@ -171,15 +430,23 @@ to Percona XtraDB Cluster through HAProxy or ProxySQL.
 
 This tool helps identify connection issues during pod rolling updates,
 network partitions, or proxy failovers by showing the full connection path.`,
-		Run: runMonitor,
+		Run:     runMonitor,
+		Version: versionString(),
 	}
+	rootCmd.SetVersionTemplate("{{.Name}} {{.Version}}\n")
 
 	// Proxy connection flags
 	rootCmd.Flags().StringVar(&cfg.ProxyHost, "proxy-host", "localhost", "Proxy host (HAProxy or ProxySQL)")
 	rootCmd.Flags().IntVar(&cfg.ProxyPort, "proxy-port", 3306, "Proxy port")
+	rootCmd.Flags().StringSliceVar(&cfg.ProxyHosts, "proxy-hosts", []string{}, "Multiple proxy endpoints, one pool each (comma-separated host:port, overrides --proxy-host/--proxy-port)")
 	rootCmd.Flags().StringVar(&cfg.ProxyUser, "proxy-user", "root", "MySQL user")
 	rootCmd.Flags().StringVar(&cfg.ProxyPassword, "proxy-password", "", "MySQL password")
 	rootCmd.Flags().StringVar(&cfg.Database, "database", "test", "Database name")
+	rootCmd.Flags().DurationVar(&cfg.ReadTimeout, "read-timeout", 10*time.Second, "Driver I/O read timeout (readTimeout DSN param)")
+	rootCmd.Flags().DurationVar(&cfg.WriteTimeout, "write-timeout", 10*time.Second, "Driver I/O write timeout (writeTimeout DSN param)")
+	rootCmd.Flags().StringVar(&cfg.TLSMode, "tls-mode", "disabled", "TLS for proxy and ProxySQL admin connections: disabled, preferred, skip-verify or verify")
+	rootCmd.Flags().StringVar(&cfg.TLSCA, "tls-ca", "", "CA bundle (PEM) used to verify server certificates with --tls-mode=verify")
+	rootCmd.Flags().StringVar(&cfg.DSNParams, "dsn-params", "", "Extra driver DSN params passed through as-is (e.g. interpolateParams=true&collation=utf8mb4_general_ci)")
 
 	// HAProxy stats flags
 	rootCmd.Flags().StringVar(&cfg.HAProxyStatsURL, "haproxy-stats-url", "http://localhost:8404/stats", "HAProxy stats URL")
@@ -193,30 +460,44 @@ network partitions, or proxy failovers by showing the full connection path.`,
 	rootCmd.Flags().StringVar(&cfg.ProxySQLAdminPassword, "proxysql-admin-password", "admin", "ProxySQL admin password")
 
 	// PXC node flags
-	rootCmd.Flags().StringSliceVar(&cfg.PXCNodes, "pxc-nodes", []string{}, "PXC node addresses (comma-separated, e.g., node1:3306,node2:3306)")
+	rootCmd.Flags().StringSliceVar(&cfg.PXCNodes, "pxc-nodes", []string{}, "PXC node addresses (comma-separated host[:port], IPv6 bracketed, e.g., node1,node2:3307,[fd00::1]:3306)")
+	rootCmd.Flags().IntVar(&cfg.PXCPort, "pxc-port", 3306, "Port for --pxc-nodes entries that don't specify one")
 	rootCmd.Flags().StringVar(&cfg.PXCUser, "pxc-user", "", "PXC direct user (defaults to proxy-user)")
 	rootCmd.Flags().StringVar(&cfg.PXCPassword, "pxc-password", "", "PXC direct password (defaults to proxy-password)")
+	rootCmd.Flags().BoolVar(&cfg.ProbeNodes, "probe-nodes", false, "Time a SELECT 1 directly against each PXC node every refresh, over a connection kept open between refreshes")
+	rootCmd.Flags().IntVar(&cfg.FCSustainedRefreshes, "fc-sustained-refreshes", 3, "Flag a node red after this many consecutive refreshes with flow control pauses")
+	rootCmd.Flags().IntVar(&cfg.MaxConnectionsWarn, "max-connections-warn", 80, "Flag a node red when its connections reach this percent of @@max_connections (0 disables)")
 
 	// Pool settings
 	rootCmd.Flags().IntVar(&cfg.PoolSize, "pool-size", 10, "Connection pool size (like HikariCP maximumPoolSize)")
-	rootCmd.Flags().IntVar(&cfg.MinIdle, "min-idle", 2, "Minimum idle connections (like HikariCP minimumIdle)")
+	rootCmd.Flags().IntVar(&cfg.MinIdle, "min-idle", 2, "Minimum idle connections, opened at startup (like HikariCP minimumIdle)")
 	rootCmd.Flags().DurationVar(&cfg.MaxLifetime, "max-lifetime", 30*time.Minute, "Maximum connection lifetime (like HikariCP maxLifetime)")
 	rootCmd.Flags().DurationVar(&cfg.IdleTimeout, "idle-timeout", 10*time.Minute, "Idle connection timeout (like HikariCP idleTimeout)")
 	rootCmd.Flags().DurationVar(&cfg.ConnectionTimeout, "connection-timeout", 30*time.Second, "Connection timeout (like HikariCP connectionTimeout)")
 	rootCmd.Flags().DurationVar(&cfg.ValidationInterval, "validation-interval", 5*time.Second, "Connection validation interval")
+	rootCmd.Flags().DurationVar(&cfg.ReconnectBackoff, "reconnect-backoff", 500*time.Millisecond, "Initial pause after a failed connection acquisition (0 disables backoff)")
+	rootCmd.Flags().DurationVar(&cfg.ReconnectMax, "reconnect-max", 30*time.Second, "Maximum reconnect backoff")
 
 	// Workload settings
 	rootCmd.Flags().IntVar(&cfg.ReadQPS, "read-qps", 10, "Read queries per second")
 	rootCmd.Flags().IntVar(&cfg.WriteQPS, "write-qps", 2, "Write queries per second")
+	rootCmd.Flags().StringVar(&cfg.BackendIDQuery, "backend-id-query", "SELECT @@hostname", "Single-value query identifying the backend node (e.g. SELECT @@wsrep_node_name)")
+	rootCmd.Flags().BoolVar(&cfg.ConsistencyCheck, "consistency-check", false, "Verify each written row is visible to a later read through the proxy")
+	rootCmd.Flags().DurationVar(&cfg.ConsistencyGrace, "consistency-grace", time.Second, "How long a written row may stay invisible before the read counts as stale")
 
 	// Mode
 	rootCmd.Flags().BoolVar(&cfg.UseProxySQL, "proxysql", false, "Use ProxySQL mode instead of HAProxy")
-	rootCmd.Flags().BoolVar(&cfg.Verbose, "verbose", false, "Verbose output")
-
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	rootCmd.Flags().BoolVar(&cfg.Both, "both", false, "Show HAProxy and ProxySQL panels together (needs --haproxy-stats-url and --proxysql-admin-host)")
+	rootCmd.Flags().DurationVar(&cfg.StatsTimeout, "stats-timeout", 5*time.Second, "Per-refresh timeout for each HAProxy stats, ProxySQL admin and PXC node fetch")
+	rootCmd.Flags().BoolVar(&cfg.Verbose, "verbose", false, "Trace every read/write (connection id, backend, latency, error) to --log-file (requires --log-file)")
+	rootCmd.Flags().StringVar(&cfg.LogFile, "log-file", "", "Append every connection error as a JSON line to this file")
+	rootCmd.Flags().BoolVar(&cfg.Quiet, "quiet", false, "Don't render the dashboard, only write --log-file")
+	rootCmd.Flags().StringVar(&cfg.Output, "output", "table", "Dashboard output: table, or json for one JSON report per refresh on stdout")
+	rootCmd.Flags().StringVar(&cfg.HTTPAddr, "http-addr", "", "Serve an auto-refreshing HTML status page on this address (e.g. :8090)")
+	rootCmd.Flags().BoolVar(&cfg.StressExhaust, "stress-exhaust", false, "Hold connections open in-transaction until the pool is exhausted (pool-size+1 per endpoint)")
+	rootCmd.Flags().DurationVar(&cfg.StressRamp, "stress-ramp", 30*time.Second, "Time over which --stress-exhaust ramps up held connections")
+
+	return rootCmd
 }
 
 func runMonitor(cmd *cobra.Command, args []string) {
@@ -236,51 +517,379 @@ func runMonitor(cmd *cobra.Command, args []string) {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\nShutting down...")
+		fmt.Fprintln(os.Stderr, "\nShutting down...")
 		cancel()
 	}()
 
-	// Create connection pool
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?timeout=%s&readTimeout=10s&writeTimeout=10s",
-		cfg.ProxyUser, cfg.ProxyPassword, cfg.ProxyHost, cfg.ProxyPort, cfg.Database,
-		cfg.ConnectionTimeout.String())
+	if q := strings.ToUpper(backendIDQuery()); !strings.HasPrefix(q, "SELECT ") {
+		color.Red("--backend-id-query must be a single SELECT statement, got %q", cfg.BackendIDQuery)
+		os.Exit(1)
+	}
+
+	if cfg.Quiet && cfg.LogFile == "" {
+		color.Red("--quiet requires --log-file")
+		os.Exit(1)
+	}
+	if cfg.Verbose && cfg.LogFile == "" {
+		color.Red("--verbose requires --log-file")
+		os.Exit(1)
+	}
+	switch cfg.Output {
+	case "table":
+	case "json":
+		if cfg.Quiet {
+			color.Red("--quiet and --output json are mutually exclusive")
+			os.Exit(1)
+		}
+	default:
+		color.Red("invalid --output %q (expected table or json)", cfg.Output)
+		os.Exit(1)
+	}
+	if cfg.LogFile != "" {
+		l, err := openErrorLog(cfg.LogFile)
+		if err != nil {
+			color.Red("Failed to open --log-file: %v", err)
+			os.Exit(1)
+		}
+		errorLog = l
+		defer errorLog.Close()
+	}
 
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		color.Red("Failed to create connection pool: %v", err)
+	if err := checkBothEndpoints(cmd); err != nil {
+		color.Red("%v", err)
 		os.Exit(1)
 	}
-	defer db.Close()
 
-	// Configure pool (HikariCP-like settings)
-	db.SetMaxOpenConns(cfg.PoolSize)
-	db.SetMaxIdleConns(cfg.MinIdle)
-	db.SetConnMaxLifetime(cfg.MaxLifetime)
-	db.SetConnMaxIdleTime(cfg.IdleTimeout)
+	if cfg.StatsTimeout <= 0 {
+		color.Red("--stats-timeout must be a positive duration, got %s", cfg.StatsTimeout)
+		os.Exit(1)
+	}
 
-	// Ensure test table exists
-	if err := ensureTestTable(ctx, db); err != nil {
-		color.Red("Failed to create test table: %v", err)
+	if cfg.MaxConnectionsWarn < 0 || cfg.MaxConnectionsWarn > 100 {
+		color.Red("--max-connections-warn must be a percentage between 0 and 100, got %d", cfg.MaxConnectionsWarn)
 		os.Exit(1)
 	}
 
-	var wg sync.WaitGroup
+	if cfg.ReconnectBackoff > 0 && cfg.ReconnectMax < cfg.ReconnectBackoff {
+		color.Red("--reconnect-max (%s) must not be less than --reconnect-backoff (%s)", cfg.ReconnectMax, cfg.ReconnectBackoff)
+		os.Exit(1)
+	}
+
+	if err := setupTLS(); err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	if _, err := proxyDSNParams(); err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	endpoints, err := proxyEndpoints()
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	if cfg.PXCNodes, err = pxcNodeAddrs(cfg.PXCNodes, cfg.PXCPort); err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+	defer closePXCNodeDBs()
+
+	// Create one connection pool per proxy endpoint
+	var pools []*ProxyPool
+	for _, addr := range endpoints {
+		db, err := openProxyPool(addr)
+		if err != nil {
+			color.Red("Failed to create connection pool for %s: %v", addr, err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		// Ensure test table exists
+		if err := ensureTestTable(ctx, db); err != nil {
+			color.Red("Failed to create test table via %s: %v", addr, err)
+			os.Exit(1)
+		}
+
+		// Pre-open --min-idle connections the way HikariCP fills minimumIdle
+		if opened, err := warmupPool(ctx, db, cfg.MinIdle); err != nil {
+			color.Yellow("Warmup via %s opened %d/%d connections: %v", addr, opened, cfg.MinIdle, err)
+		}
+
+		pools = append(pools, &ProxyPool{Addr: addr, DB: db, Backoff: &ReconnectBackoff{}, Consistency: &ConsistencyChecker{}, WarmedUp: time.Now()})
+	}
+
+	// A bad --backend-id-query would otherwise label every read "unknown"
+	if err := validateBackendIDQuery(ctx, pools[0].DB); err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
 
-	// Start workload generator
-	wg.Add(1)
+	// Zero the counters on SIGUSR1 so a failover can be measured from a clean baseline
+	resetCh := make(chan os.Signal, 1)
+	signal.Notify(resetCh, syscall.SIGUSR1)
 	go func() {
-		defer wg.Done()
-		runWorkload(ctx, db)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-resetCh:
+				resetStats(pools)
+			}
+		}
 	}()
 
+	var wg sync.WaitGroup
+
+	// Start a workload generator per pool
+	for _, p := range pools {
+		wg.Add(1)
+		go func(p *ProxyPool) {
+			defer wg.Done()
+			runWorkload(ctx, p)
+		}(p)
+	}
+
 	// Start monitoring display
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		runMonitorDisplay(ctx, db)
-	}()
+	switch {
+	case cfg.Quiet:
+		fmt.Printf("Dashboard disabled, logging connection errors to %s (Ctrl+C to exit)\n", cfg.LogFile)
+	case cfg.Output == "json":
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runJSONOutput(ctx, pools)
+		}()
+	default:
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runMonitorDisplay(ctx, pools)
+		}()
+	}
+
+	// Start pool exhaustion stress
+	if cfg.StressExhaust {
+		for _, p := range pools {
+			wg.Add(1)
+			go func(p *ProxyPool) {
+				defer wg.Done()
+				runStressExhaust(ctx, p)
+			}(p)
+		}
+	}
+
+	// Start HTML status page
+	if cfg.HTTPAddr != "" {
+		// Without the dashboard nothing else fetches what the page shows
+		if cfg.Quiet {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runSnapshotLoop(ctx)
+			}()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runStatusServer(ctx, pools)
+		}()
+	}
 
 	wg.Wait()
+
+	// Keep stdout parseable as JSON lines
+	if cfg.Output != "json" {
+		printErrorSummary()
+	}
+}
+
+// checkBothEndpoints makes --both require the HAProxy stats URL and ProxySQL
+// admin host to be given explicitly. Both flags have localhost defaults, so
+// checking for empty values would let a comparison silently watch the wrong
+// proxy.
+func checkBothEndpoints(cmd *cobra.Command) error {
+	if !cfg.Both {
+		return nil
+	}
+	flags := cmd.Flags()
+	if !flags.Changed("haproxy-stats-url") || !flags.Changed("proxysql-admin-host") {
+		return errors.New("--both needs both --haproxy-stats-url and --proxysql-admin-host")
+	}
+	return nil
+}
+
+// proxyEndpoints returns the host:port addresses to open pools against.
+// --proxy-hosts takes precedence over --proxy-host/--proxy-port.
+func proxyEndpoints() ([]string, error) {
+	if len(cfg.ProxyHosts) == 0 {
+		return []string{net.JoinHostPort(cfg.ProxyHost, strconv.Itoa(cfg.ProxyPort))}, nil
+	}
+
+	for _, addr := range cfg.ProxyHosts {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("invalid --proxy-hosts entry %q: %v", addr, err)
+		}
+	}
+	return cfg.ProxyHosts, nil
+}
+
+// pxcNodeAddrs validates --pxc-nodes entries and returns them in canonical
+// host:port form, so IPv6 literals are bracketed ("[::1]:3306") before they
+// are placed in a tcp(...) DSN. Entries without a port get defaultPort. A bare
+// IPv6 literal is still rejected because "fd00::1:3306" can't be told apart
+// from an address with a port; "[fd00::1]" takes the default.
+func pxcNodeAddrs(nodes []string, defaultPort int) ([]string, error) {
+	if defaultPort < 1 || defaultPort > 65535 {
+		return nil, fmt.Errorf("--pxc-port must be 1-65535, got %d", defaultPort)
+	}
+
+	addrs := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		entry := strings.TrimSpace(node)
+		host, port, err := net.SplitHostPort(entry)
+		if err != nil {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() == nil {
+				return nil, fmt.Errorf("invalid --pxc-nodes entry %q: IPv6 addresses must be bracketed, e.g. [%s] or [%s]:3306", node, entry, entry)
+			}
+			var addrErr *net.AddrError
+			if !errors.As(err, &addrErr) || addrErr.Err != "missing port in address" {
+				return nil, fmt.Errorf("invalid --pxc-nodes entry %q: %v", node, err)
+			}
+			host, port = strings.Trim(entry, "[]"), strconv.Itoa(defaultPort)
+		}
+		if host == "" {
+			return nil, fmt.Errorf("invalid --pxc-nodes entry %q: missing host", node)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return nil, fmt.Errorf("invalid --pxc-nodes entry %q: port must be 1-65535", node)
+		}
+		addrs = append(addrs, net.JoinHostPort(host, port))
+	}
+	return addrs, nil
+}
+
+// proxyDSNParams builds the driver params for proxy connections from the
+// timeout flags plus any --dsn-params passthrough
+func proxyDSNParams() (url.Values, error) {
+	for name, d := range map[string]time.Duration{
+		"--connection-timeout": cfg.ConnectionTimeout,
+		"--read-timeout":       cfg.ReadTimeout,
+		"--write-timeout":      cfg.WriteTimeout,
+	} {
+		if d <= 0 {
+			return nil, fmt.Errorf("%s must be a positive duration, got %s", name, d)
+		}
+	}
+
+	params := url.Values{}
+	params.Set("timeout", cfg.ConnectionTimeout.String())
+	params.Set("readTimeout", cfg.ReadTimeout.String())
+	params.Set("writeTimeout", cfg.WriteTimeout.String())
+	if tlsDSNParam != "" {
+		params.Set("tls", tlsDSNParam)
+	}
+
+	if cfg.DSNParams == "" {
+		return params, nil
+	}
+
+	extra, err := url.ParseQuery(strings.TrimPrefix(cfg.DSNParams, "?"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --dsn-params %q: %v", cfg.DSNParams, err)
+	}
+	for key, values := range extra {
+		// Timeouts and TLS have dedicated flags; don't let two sources disagree
+		if params.Has(key) {
+			return nil, fmt.Errorf("--dsn-params must not set %q, use the dedicated flag instead", key)
+		}
+		for _, v := range values {
+			params.Add(key, v)
+		}
+	}
+	return params, nil
+}
+
+// tlsConfigName is the driver TLS config registered for --tls-ca
+const tlsConfigName = "connpool-monitor"
+
+// tlsDSNParam is the driver's tls DSN value for --tls-mode, empty when TLS is off
+var tlsDSNParam string
+
+// setupTLS resolves --tls-mode/--tls-ca into tlsDSNParam, registering a
+// custom driver TLS config when a CA bundle is given
+func setupTLS() error {
+	switch cfg.TLSMode {
+	case "", "disabled":
+		if cfg.TLSCA != "" {
+			return errors.New("--tls-ca requires --tls-mode=verify")
+		}
+		tlsDSNParam = ""
+		return nil
+	case "preferred", "skip-verify":
+		if cfg.TLSCA != "" {
+			return errors.New("--tls-ca requires --tls-mode=verify")
+		}
+		tlsDSNParam = cfg.TLSMode
+		return nil
+	case "verify":
+	default:
+		return fmt.Errorf("invalid --tls-mode %q (expected disabled, preferred, skip-verify or verify)", cfg.TLSMode)
+	}
+
+	if cfg.TLSCA == "" {
+		// Verify against the system roots
+		tlsDSNParam = "true"
+		return nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLSCA)
+	if err != nil {
+		return fmt.Errorf("failed to read --tls-ca: %v", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("--tls-ca %s contains no PEM certificates", cfg.TLSCA)
+	}
+	if err := mysql.RegisterTLSConfig(tlsConfigName, &tls.Config{RootCAs: roots}); err != nil {
+		return fmt.Errorf("failed to register TLS config: %v", err)
+	}
+	tlsDSNParam = tlsConfigName
+	return nil
+}
+
+// buildProxyDSN returns the driver DSN for a single proxy endpoint
+func buildProxyDSN(addr string) (string, error) {
+	params, err := proxyDSNParams()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?%s",
+		cfg.ProxyUser, cfg.ProxyPassword, addr, cfg.Database, params.Encode()), nil
+}
+
+// openProxyPool opens a HikariCP-like pool against a single proxy endpoint
+func openProxyPool(addr string) (*sql.DB, error) {
+	dsn, err := buildProxyDSN(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configure pool (HikariCP-like settings)
+	db.SetMaxOpenConns(cfg.PoolSize)
+	db.SetMaxIdleConns(cfg.MinIdle)
+	db.SetConnMaxLifetime(cfg.MaxLifetime)
+	db.SetConnMaxIdleTime(cfg.IdleTimeout)
+
+	return db, nil
 }
 
 func ensureTestTable(ctx context.Context, db *sql.DB) error {
@@ -295,7 +904,7 @@ func ensureTestTable(ctx context.Context, db *sql.DB) error {
 	return err
 }
 
-func runWorkload(ctx context.Context, db *sql.DB) {
+func runWorkload(ctx context.Context, p *ProxyPool) {
 	readTicker := time.NewTicker(time.Second / time.Duration(cfg.ReadQPS))
 	writeTicker := time.NewTicker(time.Second / time.Duration(cfg.WriteQPS))
 	defer readTicker.Stop()
@@ -306,47 +915,60 @@ func runWorkload(ctx context.Context, db *sql.DB) {
 		case <-ctx.Done():
 			return
 		case <-readTicker.C:
-			go executeRead(ctx, db)
+			if !p.Backoff.Paused() {
+				go executeRead(ctx, p)
+			}
 		case <-writeTicker.C:
-			go executeWrite(ctx, db)
+			if !p.Backoff.Paused() {
+				go executeWrite(ctx, p)
+			}
 		}
 	}
 }
 
-func executeRead(ctx context.Context, db *sql.DB) {
-	start := time.Now()
+func executeRead(ctx context.Context, p *ProxyPool) {
+	acquireStart := time.Now()
+	trace := QueryTrace{Endpoint: p.Addr, Operation: "read"}
 
 	// Get connection info first
-	var connID int
+	var connID int64
 	var backendHost string
 
-	conn, err := db.Conn(ctx)
+	conn, err := acquireConn(ctx, p)
 	if err != nil {
 		recordError("read_conn", err, "")
+		trace.Acquire, trace.Err = time.Since(acquireStart), err
+		traceQuery(trace)
 		return
 	}
 	defer conn.Close()
+	trace.Acquire = time.Since(acquireStart)
+	recordAcquire(trace.Acquire)
+
+	start := time.Now()
 
 	// Get connection ID and backend info
 	err = conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&connID)
 	if err != nil {
 		recordError("read_connid", err, "")
+		trace.Latency, trace.Err = time.Since(start), err
+		traceQuery(trace)
 		return
 	}
+	trace.ConnID = connID
 
 	// Try to get the backend host
-	err = conn.QueryRowContext(ctx, "SELECT @@hostname").Scan(&backendHost)
-	if err != nil {
-		backendHost = "unknown"
-	}
+	backendHost, serverID := backendIdentity(ctx, conn)
+	trace.Node = backendHost
 
 	// Execute read query
 	rows, err := conn.QueryContext(ctx, "SELECT id, data FROM connpool_test ORDER BY id DESC LIMIT 10")
 	if err != nil {
 		recordError("read", err, backendHost)
+		trace.Latency, trace.Err = time.Since(start), err
+		traceQuery(trace)
 		return
 	}
-	defer rows.Close()
 
 	// Consume results
 	for rows.Next() {
@@ -354,73 +976,226 @@ func executeRead(ctx context.Context, db *sql.DB) {
 		var data string
 		rows.Scan(&id, &data)
 	}
+	rows.Close()
 
 	latency := time.Since(start)
+	trace.Latency = latency
+	traceQuery(trace)
+
+	recordRead(latency, backendHost, serverID)
 
+	// After the timed section, so the check's own query isn't read latency
+	if cfg.ConsistencyCheck {
+		checkReadAfterWrite(ctx, p.Consistency, conn, backendHost)
+	}
+}
+
+// recordRead records a successful read served by backendHost
+func recordRead(latency time.Duration, backendHost string, serverID int64) {
 	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
 	stats.TotalReads++
 	stats.LastReadLatency = latency
 	stats.LastBackendNode = backendHost
-	if stats.TotalReads > 0 {
-		stats.AvgReadLatency = time.Duration((int64(stats.AvgReadLatency)*(stats.TotalReads-1) + int64(latency)) / stats.TotalReads)
+	stats.AvgReadLatency = time.Duration((int64(stats.AvgReadLatency)*(stats.TotalReads-1) + int64(latency)) / stats.TotalReads)
+	// Zero means no read has completed yet, so the first sample sets min
+	if stats.MinReadLatency == 0 || latency < stats.MinReadLatency {
+		stats.MinReadLatency = latency
 	}
-	stats.mu.Unlock()
+	if latency > stats.MaxReadLatency {
+		stats.MaxReadLatency = latency
+	}
+	trackRouting(backendHost, serverID, false)
 }
 
-func executeWrite(ctx context.Context, db *sql.DB) {
-	start := time.Now()
+func executeWrite(ctx context.Context, p *ProxyPool) {
+	acquireStart := time.Now()
+	trace := QueryTrace{Endpoint: p.Addr, Operation: "write"}
 
-	conn, err := db.Conn(ctx)
+	conn, err := acquireConn(ctx, p)
 	if err != nil {
 		recordError("write_conn", err, "")
+		trace.Acquire, trace.Err = time.Since(acquireStart), err
+		traceQuery(trace)
 		return
 	}
 	defer conn.Close()
+	trace.Acquire = time.Since(acquireStart)
+	recordAcquire(trace.Acquire)
 
-	// Get backend host
-	var backendHost string
-	err = conn.QueryRowContext(ctx, "SELECT @@hostname").Scan(&backendHost)
-	if err != nil {
-		backendHost = "unknown"
+	// Only the trace needs the connection id; keep it out of write latency
+	if cfg.Verbose {
+		conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&trace.ConnID)
 	}
 
+	start := time.Now()
+
+	// Get backend host
+	backendHost, serverID := backendIdentity(ctx, conn)
+	trace.Node = backendHost
+
 	// Execute write
 	data := fmt.Sprintf("test-%d", time.Now().UnixNano())
-	_, err = conn.ExecContext(ctx, "INSERT INTO connpool_test (data) VALUES (?)", data)
+	result, err := conn.ExecContext(ctx, "INSERT INTO connpool_test (data) VALUES (?)", data)
 	if err != nil {
 		recordError("write", err, backendHost)
+		trace.Latency, trace.Err = time.Since(start), err
+		traceQuery(trace)
 		return
 	}
+	if cfg.ConsistencyCheck {
+		if id, err := result.LastInsertId(); err == nil {
+			p.Consistency.Record(id)
+		}
+	}
 
 	latency := time.Since(start)
+	trace.Latency = latency
+	traceQuery(trace)
+
+	recordWrite(latency, backendHost, serverID)
+}
 
+// recordWrite records a successful write served by backendHost
+func recordWrite(latency time.Duration, backendHost string, serverID int64) {
 	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
 	stats.TotalWrites++
 	stats.LastWriteLatency = latency
 	stats.LastBackendNode = backendHost
-	if stats.TotalWrites > 0 {
-		stats.AvgWriteLatency = time.Duration((int64(stats.AvgWriteLatency)*(stats.TotalWrites-1) + int64(latency)) / stats.TotalWrites)
+	stats.AvgWriteLatency = time.Duration((int64(stats.AvgWriteLatency)*(stats.TotalWrites-1) + int64(latency)) / stats.TotalWrites)
+	// Zero means no write has completed yet, so the first sample sets min
+	if stats.MinWriteLatency == 0 || latency < stats.MinWriteLatency {
+		stats.MinWriteLatency = latency
 	}
-	stats.mu.Unlock()
+	if latency > stats.MaxWriteLatency {
+		stats.MaxWriteLatency = latency
+	}
+	trackRouting(backendHost, serverID, true)
 }
 
-func recordError(operation string, err error, node string) {
-	stats.mu.Lock()
-	defer stats.mu.Unlock()
+// backendIdentity runs --backend-id-query on conn to name the node serving
+// it. The query is wrapped as a scalar subquery next to @@server_id so both
+// values come from the same backend in a single round trip. A failed lookup
+// returns unknownServerID.
+func backendIdentity(ctx context.Context, conn *sql.Conn) (string, int64) {
+	var node sql.NullString
+	var serverID int64
+	err := conn.QueryRowContext(ctx, fmt.Sprintf("SELECT (%s), @@server_id", backendIDQuery())).Scan(&node, &serverID)
+	if err != nil {
+		return "unknown", unknownServerID
+	}
+	if !node.Valid {
+		return "unknown", serverID
+	}
+	return node.String, serverID
+}
 
-	switch {
-	case strings.HasPrefix(operation, "read"):
-		stats.FailedReads++
-	case strings.HasPrefix(operation, "write"):
-		stats.FailedWrites++
+// validateBackendIDQuery runs --backend-id-query once through db so a typo
+// fails at startup instead of being swallowed by backendIdentity
+func validateBackendIDQuery(ctx context.Context, db *sql.DB) error {
+	var node sql.NullString
+	var serverID int64
+	err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT (%s), @@server_id", backendIDQuery())).Scan(&node, &serverID)
+	if err != nil {
+		return fmt.Errorf("--backend-id-query %q failed: %w", cfg.BackendIDQuery, err)
 	}
-	stats.FailedConnections++
+	return nil
+}
 
-	connErr := ConnectionError{
-		Timestamp: time.Now(),
-		Operation: operation,
+// backendIDQuery returns --backend-id-query without a trailing semicolon
+func backendIDQuery() string {
+	return strings.TrimRight(strings.TrimSpace(cfg.BackendIDQuery), "; ")
+}
+
+// acquireConn takes a connection from the pool, feeding the outcome into the
+// pool's reconnect backoff. Attempts made while backing off count as
+// reconnect attempts.
+func acquireConn(ctx context.Context, p *ProxyPool) (*sql.Conn, error) {
+	if failures, _ := p.Backoff.State(); failures > 0 {
+		stats.mu.Lock()
+		stats.ReconnectAttempts++
+		stats.mu.Unlock()
+	}
+
+	// Like HikariCP's connectionTimeout, give up waiting for a pooled
+	// connection after --connection-timeout instead of blocking forever
+	acquireCtx, cancel := context.WithTimeout(ctx, cfg.ConnectionTimeout)
+	defer cancel()
+
+	conn, err := p.DB.Conn(acquireCtx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.Backoff.Fail()
+		}
+		return nil, err
+	}
+	p.Backoff.Succeed()
+	return conn, nil
+}
+
+// trackRouting counts a successful operation against the server that
+// served it. Keying by @@server_id keeps two nodes sharing a hostname, or a
+// --backend-id-query that isn't unique, from being merged. Callers must hold
+// stats.mu.
+func trackRouting(node string, serverID int64, write bool) {
+	if stats.Routing == nil {
+		stats.Routing = make(map[int64]*NodeRouting)
+	}
+	r, ok := stats.Routing[serverID]
+	if !ok {
+		r = &NodeRouting{ServerID: serverID}
+		stats.Routing[serverID] = r
+	}
+	r.Node = node
+	if write {
+		r.Writes++
+	} else {
+		r.Reads++
+	}
+}
+
+// recordAcquire records how long it took to obtain a connection from the pool
+func recordAcquire(latency time.Duration) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	stats.TotalAcquires++
+	stats.LastAcquireLatency = latency
+	stats.AvgAcquireLatency = time.Duration((int64(stats.AvgAcquireLatency)*(stats.TotalAcquires-1) + int64(latency)) / stats.TotalAcquires)
+	if latency > stats.MaxAcquireLatency {
+		stats.MaxAcquireLatency = latency
+	}
+}
+
+func recordError(operation string, err error, node string) {
+	stats.mu.Lock()
+
+	switch {
+	case strings.HasPrefix(operation, "read"):
+		stats.FailedReads++
+	case strings.HasPrefix(operation, "write"):
+		stats.FailedWrites++
+	}
+	stats.FailedConnections++
+
+	category := errorCategory(err)
+	if stats.ErrorCategories == nil {
+		stats.ErrorCategories = make(map[string]int64)
+	}
+	stats.ErrorCategories[category]++
+
+	now := time.Now()
+	groupError(err.Error(), now)
+
+	connErr := ConnectionError{
+		Timestamp: now,
+		Operation: operation,
 		Error:     err.Error(),
 		Node:      node,
+		Category:  category,
 	}
 	stats.ConnectionErrors = append(stats.ConnectionErrors, connErr)
 
@@ -428,9 +1203,113 @@ func recordError(operation string, err error, node string) {
 	if len(stats.ConnectionErrors) > 100 {
 		stats.ConnectionErrors = stats.ConnectionErrors[len(stats.ConnectionErrors)-100:]
 	}
+	stats.mu.Unlock()
+
+	// A slow --log-file disk must not stall the workers and the dashboard
+	// waiting on stats.mu
+	errorLog.Write(connErr)
+}
+
+// resetStats zeroes the counters, latencies and error history while leaving
+// the connection pools open. Driver wait/close counters can't be reset, so
+// each pool records a baseline instead.
+func resetStats(pools []*ProxyPool) {
+	for _, p := range pools {
+		p.ResetBaseline()
+	}
+	atomic.StoreInt64(&readOps, 0)
+	atomic.StoreInt64(&writeOps, 0)
+	poolHistory.Reset()
+	poolEfficiency.Reset()
+	proxySQLStatusHistory.Reset()
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	stats.TotalConnections = 0
+	stats.FailedConnections = 0
+	stats.ReconnectAttempts = 0
+	stats.TotalReads = 0
+	stats.TotalWrites = 0
+	stats.FailedReads = 0
+	stats.FailedWrites = 0
+	stats.LastReadLatency = 0
+	stats.LastWriteLatency = 0
+	stats.AvgReadLatency = 0
+	stats.AvgWriteLatency = 0
+	stats.MinReadLatency = 0
+	stats.MaxReadLatency = 0
+	stats.MinWriteLatency = 0
+	stats.MaxWriteLatency = 0
+	stats.TotalAcquires = 0
+	stats.LastAcquireLatency = 0
+	stats.AvgAcquireLatency = 0
+	stats.MaxAcquireLatency = 0
+	stats.ConnectionErrors = nil
+	stats.ErrorCategories = nil
+	stats.ErrorGroups = nil
+	stats.Routing = nil
+	stats.ConsistencyChecks = 0
+	stats.StaleReads = 0
+	stats.LastStaleRead = time.Time{}
+	stats.LastStaleReadNode = ""
+	stats.ResetAt = time.Now()
+}
+
+// errorCategory classifies an error so pool exhaustion (1040) can be told
+// apart from node loss (2006/2013), lock contention and network failures
+func errorCategory(err error) string {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1040:
+			return ErrCategoryTooManyConnections
+		case 1205:
+			return ErrCategoryLockWaitTimeout
+		case 1213:
+			return ErrCategoryDeadlock
+		case 2006:
+			return ErrCategoryGoneAway
+		case 2013:
+			return ErrCategoryLostConnection
+		}
+	}
+
+	if errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, driver.ErrBadConn) {
+		return ErrCategoryGoneAway
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrCategoryRefused
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrCategoryTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrCategoryTimeout
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return ErrCategoryLostConnection
+	}
+
+	// Fall back to message matching for errors that lost their type
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "too many connections"):
+		return ErrCategoryTooManyConnections
+	case strings.Contains(msg, "gone away"), strings.Contains(msg, "invalid connection"):
+		return ErrCategoryGoneAway
+	case strings.Contains(msg, "lost connection"), strings.Contains(msg, "connection reset"), strings.Contains(msg, "broken pipe"):
+		return ErrCategoryLostConnection
+	case strings.Contains(msg, "connection refused"):
+		return ErrCategoryRefused
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "deadline exceeded"):
+		return ErrCategoryTimeout
+	}
+	return ErrCategoryOther
 }
 
-func runMonitorDisplay(ctx context.Context, db *sql.DB) {
+func runMonitorDisplay(ctx context.Context, pools []*ProxyPool) {
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -441,20 +1320,49 @@ func runMonitorDisplay(ctx context.Context, db *sql.DB) {
 			return
 		case <-ticker.C:
 			clearScreen()
-			printHeader()
-			printPoolStats(db)
+			poolHistory.Sample(pools)
+			renderDashboard(ctx, pools)
+		}
+	}
+}
 
-			if cfg.UseProxySQL {
-				printProxySQLStats(ctx)
-			} else {
-				printHAProxyStats()
-			}
+// renderDashboard prints one refresh of every dashboard panel
+func renderDashboard(ctx context.Context, pools []*ProxyPool) {
+	printHeader()
+	printPoolStats(pools)
+	if cfg.StressExhaust {
+		printStressStats(pools)
+	}
 
-			printPXCStatus(ctx)
-			printConnectionErrors()
-			printFooter()
-		}
+	if showHAProxyPanel() {
+		printHAProxyStats(ctx)
+	}
+	if showProxySQLPanel() {
+		printProxySQLStats(ctx)
+	}
+
+	printPXCStatus(ctx)
+	printRoutingStats()
+	printErrorBreakdown()
+	printConnectionErrors()
+	printFooter(pools)
+}
+
+// statsContext bounds one stats fetch by --stats-timeout, so a hung HAProxy,
+// ProxySQL admin or PXC node shows up as a timeout instead of freezing the
+// display loop
+func statsContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, cfg.StatsTimeout)
+}
+
+// statsFetchError replaces a deadline error from a stats fetch with a short
+// "timed out" message
+func statsFetchError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("timed out after %s", cfg.StatsTimeout)
 	}
+	return err
 }
 
 func clearScreen() {
@@ -467,22 +1375,42 @@ func printHeader() {
 	bold.Println("  CONNECTION POOL MONITOR - HAProxy/ProxySQL to PXC Cluster")
 	bold.Println("===============================================================================")
 	fmt.Printf("  Mode: %s | Time: %s\n", getModeString(), time.Now().Format("15:04:05"))
+
+	stats.mu.RLock()
+	resetAt := stats.ResetAt
+	stats.mu.RUnlock()
+	if !resetAt.IsZero() {
+		color.Yellow("  --- stats reset at %s (%s ago) ---", resetAt.Format("15:04:05"), time.Since(resetAt).Round(time.Second))
+	}
 	fmt.Println()
 }
 
+// showHAProxyPanel and showProxySQLPanel report which proxy panels to render;
+// --both shows them side by side while migrating from one to the other
+func showHAProxyPanel() bool {
+	return cfg.Both || !cfg.UseProxySQL
+}
+
+func showProxySQLPanel() bool {
+	return cfg.Both || cfg.UseProxySQL
+}
+
 func getModeString() string {
+	if cfg.Both {
+		return color.YellowString("HAProxy") + " + " + color.CyanString("ProxySQL")
+	}
 	if cfg.UseProxySQL {
 		return color.CyanString("ProxySQL")
 	}
 	return color.YellowString("HAProxy")
 }
 
-func printPoolStats(db *sql.DB) {
+func printPoolStats(pools []*ProxyPool) {
 	bold := color.New(color.Bold)
 	bold.Println("[CONNECTION POOL STATUS] (HikariCP-like)")
 	fmt.Println(strings.Repeat("-", 79))
 
-	dbStats := db.Stats()
+	dbStats := combinedDBStats(pools)
 
 	stats.mu.RLock()
 	defer stats.mu.RUnlock()
@@ -493,7 +1421,7 @@ func printPoolStats(db *sql.DB) {
 	table.SetColumnSeparator("|")
 
 	table.Append([]string{
-		"Pool Size", fmt.Sprintf("%d/%d", dbStats.OpenConnections, cfg.PoolSize),
+		"Pool Size", fmt.Sprintf("%d/%d", dbStats.OpenConnections, cfg.PoolSize*len(pools)),
 		"In Use", fmt.Sprintf("%d", dbStats.InUse),
 	})
 	table.Append([]string{
@@ -516,6 +1444,23 @@ func printPoolStats(db *sql.DB) {
 		"Avg Read Latency", stats.AvgReadLatency.String(),
 		"Avg Write Latency", stats.AvgWriteLatency.String(),
 	})
+	table.Append([]string{
+		"Min/Max Read", fmt.Sprintf("%s / %s", stats.MinReadLatency, stats.MaxReadLatency),
+		"Min/Max Write", fmt.Sprintf("%s / %s", stats.MinWriteLatency, stats.MaxWriteLatency),
+	})
+	// Query latency excludes acquisition, so combine read and write samples
+	var avgQuery time.Duration
+	if total := stats.TotalReads + stats.TotalWrites; total > 0 {
+		avgQuery = time.Duration((int64(stats.AvgReadLatency)*stats.TotalReads + int64(stats.AvgWriteLatency)*stats.TotalWrites) / total)
+	}
+	maxQuery := stats.MaxReadLatency
+	if stats.MaxWriteLatency > maxQuery {
+		maxQuery = stats.MaxWriteLatency
+	}
+	table.Append([]string{
+		"Acquire Avg/Max", fmt.Sprintf("%s / %s", stats.AvgAcquireLatency, stats.MaxAcquireLatency),
+		"Query Avg/Max", fmt.Sprintf("%s / %s", avgQuery, maxQuery),
+	})
 	table.Append([]string{
 		"Last Backend", stats.LastBackendNode,
 		"Wait Duration", dbStats.WaitDuration.String(),
@@ -523,6 +1468,59 @@ func printPoolStats(db *sql.DB) {
 
 	table.Render()
 	fmt.Println()
+	printPoolTrends()
+	printPoolEfficiency(dbStats, stats.TotalReads+stats.TotalWrites+stats.FailedReads+stats.FailedWrites, cfg.PoolSize*len(pools))
+	printMinIdleCheck(pools)
+
+	if len(pools) > 1 {
+		printEndpointPoolStats(pools)
+	}
+}
+
+// combinedDBStats sums the pool statistics of every proxy endpoint
+func combinedDBStats(pools []*ProxyPool) sql.DBStats {
+	var total sql.DBStats
+	for _, p := range pools {
+		s := p.Stats()
+		total.MaxOpenConnections += s.MaxOpenConnections
+		total.OpenConnections += s.OpenConnections
+		total.InUse += s.InUse
+		total.Idle += s.Idle
+		total.WaitCount += s.WaitCount
+		total.WaitDuration += s.WaitDuration
+		total.MaxIdleClosed += s.MaxIdleClosed
+		total.MaxIdleTimeClosed += s.MaxIdleTimeClosed
+		total.MaxLifetimeClosed += s.MaxLifetimeClosed
+	}
+	return total
+}
+
+// printEndpointPoolStats shows each proxy endpoint's pool side by side so a
+// VIP failover shows up as connections moving from one endpoint to another
+func printEndpointPoolStats(pools []*ProxyPool) {
+	bold := color.New(color.Bold)
+	bold.Println("[PROXY ENDPOINTS]")
+	fmt.Println(strings.Repeat("-", 79))
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Endpoint", "Open", "In Use", "Idle", "Wait Count", "Wait Duration", "Lifetime Closed"})
+	table.SetBorder(false)
+	table.SetColumnSeparator("|")
+
+	for _, p := range pools {
+		s := p.Stats()
+		table.Append([]string{
+			p.Addr,
+			fmt.Sprintf("%d/%d", s.OpenConnections, cfg.PoolSize),
+			fmt.Sprintf("%d", s.InUse),
+			fmt.Sprintf("%d", s.Idle),
+			fmt.Sprintf("%d", s.WaitCount),
+			s.WaitDuration.String(),
+			fmt.Sprintf("%d", s.MaxLifetimeClosed),
+		})
+	}
+	table.Render()
+	fmt.Println()
 }
 
 func formatErrorCount(count int64) string {
@@ -532,12 +1530,12 @@ func formatErrorCount(count int64) string {
 	return color.GreenString("%d", count)
 }
 
-func printHAProxyStats() {
+func printHAProxyStats(ctx context.Context) {
 	bold := color.New(color.Bold)
 	bold.Println("[HAPROXY BACKEND STATUS]")
 	fmt.Println(strings.Repeat("-", 79))
 
-	backends, err := fetchHAProxyStats()
+	backends, err := refreshHAProxy(ctx)
 	if err != nil {
 		color.Red("  Error fetching HAProxy stats: %v", err)
 		fmt.Println()
@@ -576,10 +1574,73 @@ func printHAProxyStats() {
 		})
 	}
 	table.Render()
+
+	for _, b := range backends {
+		if strings.HasPrefix(b.Status, "DOWN") {
+			color.Red("  %s DOWN: %s", b.Name, checkFailureReason(b))
+		}
+	}
+
+	if allHAProxyBackendsDown(backends) {
+		printAllBackendsDownBanner()
+	}
+	fmt.Println()
+}
+
+// refreshHAProxy fetches the HAProxy backends within --stats-timeout and
+// stores them in the snapshot
+func refreshHAProxy(ctx context.Context) ([]HAProxyBackend, error) {
+	fetchCtx, cancel := statsContext(ctx)
+	backends, err := fetchHAProxyStats(fetchCtx)
+	cancel()
+	if err != nil {
+		err = statsFetchError(err)
+	}
+	snapshot.setHAProxy(backends, err)
+	return backends, err
+}
+
+// allHAProxyBackendsDown reports whether every backend is DOWN or in MAINT.
+// Transitional ("DOWN 1/2", "UP 1/3"), NOLB, DRAIN and "no check" backends
+// still accept connections, so they don't count as down.
+func allHAProxyBackendsDown(backends []HAProxyBackend) bool {
+	if len(backends) == 0 {
+		return false
+	}
+	for _, b := range backends {
+		if b.Status != "DOWN" && !strings.HasPrefix(b.Status, "MAINT") {
+			return false
+		}
+	}
+	return true
+}
+
+// allProxySQLServersDown reports whether no backend in
+// stats_mysql_connection_pool is ONLINE. The pool shows the runtime status,
+// including SHUNNED, which the mysql_servers config table never does.
+func allProxySQLServersDown(pool []ProxySQLConnPool) bool {
+	if len(pool) == 0 {
+		return false
+	}
+	for _, p := range pool {
+		if p.Status == "ONLINE" {
+			return false
+		}
+	}
+	return true
+}
+
+func printAllBackendsDownBanner() {
+	banner := color.New(color.BgRed, color.FgWhite, color.Bold)
+	msg := "ALL BACKENDS DOWN - NO ROUTABLE DATABASE NODES"
+	pad := (79 - len(msg)) / 2
 	fmt.Println()
+	banner.Println(strings.Repeat(" ", 79))
+	banner.Println(strings.Repeat(" ", pad) + msg + strings.Repeat(" ", 79-pad-len(msg)))
+	banner.Println(strings.Repeat(" ", 79))
 }
 
-func fetchHAProxyStats() ([]HAProxyBackend, error) {
+func fetchHAProxyStats(ctx context.Context) ([]HAProxyBackend, error) {
 	url := cfg.HAProxyStatsURL
 	if !strings.Contains(url, ";csv") {
 		if strings.Contains(url, "?") {
@@ -589,8 +1650,8 @@ func fetchHAProxyStats() ([]HAProxyBackend, error) {
 		}
 	}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	req, err := http.NewRequest("GET", url, nil)
+	client := &http.Client{Timeout: cfg.StatsTimeout}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -615,27 +1676,50 @@ func fetchHAProxyStats() ([]HAProxyBackend, error) {
 		return nil, err
 	}
 
-	var backends []HAProxyBackend
-	for i, record := range records {
-		if i == 0 || len(record) < 80 {
-			continue // Skip header
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	// Look columns up by name; their positions differ between HAProxy versions
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "#"))] = i
+	}
+	for _, required := range []string{"pxname", "svname", "status"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("HAProxy CSV has no %q column", required)
 		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var backends []HAProxyBackend
+	for _, record := range records[1:] {
+		svname := field(record, "svname")
 
 		// Only include backend servers, not frontend or aggregates
-		if record[1] != "FRONTEND" && record[1] != "BACKEND" && record[0] != "" {
-			currConn, _ := strconv.Atoi(record[4])
-			maxConn, _ := strconv.Atoi(record[6])
-			sessions, _ := strconv.Atoi(record[7])
+		if svname != "FRONTEND" && svname != "BACKEND" && field(record, "pxname") != "" {
+			currConn, _ := strconv.Atoi(field(record, "scur"))
+			maxConn, _ := strconv.Atoi(field(record, "slim"))
+			sessions, _ := strconv.Atoi(field(record, "stot"))
 
 			backends = append(backends, HAProxyBackend{
-				Name:        record[1],
-				Status:      record[17],
-				Addr:        record[73],
-				CurrentConn: currConn,
-				MaxConn:     maxConn,
-				Sessions:    sessions,
-				CheckStatus: record[36],
-				LastChange:  formatDuration(record[23]),
+				Name:          svname,
+				Status:        field(record, "status"),
+				Addr:          field(record, "addr"),
+				CurrentConn:   currConn,
+				MaxConn:       maxConn,
+				Sessions:      sessions,
+				CheckStatus:   field(record, "check_status"),
+				LastChange:    formatDuration(field(record, "lastchg")),
+				CheckCode:     field(record, "check_code"),
+				CheckDuration: field(record, "check_duration"),
+				LastCheck:     field(record, "last_chk"),
 			})
 		}
 	}
@@ -643,6 +1727,47 @@ func fetchHAProxyStats() ([]HAProxyBackend, error) {
 	return backends, nil
 }
 
+// haproxyCheckStatuses explains the failing HAProxy check_status codes
+var haproxyCheckStatuses = map[string]string{
+	"UNK":     "unknown",
+	"SOCKERR": "socket error",
+	"L4TOUT":  "layer 4 timeout",
+	"L4CON":   "layer 4 connection refused",
+	"L6TOUT":  "layer 6 (SSL) timeout",
+	"L6RSP":   "layer 6 invalid response",
+	"L7TOUT":  "layer 7 timeout",
+	"L7RSP":   "layer 7 invalid response",
+	"L7STS":   "layer 7 bad status",
+}
+
+// checkFailureReason describes why a backend's last health check failed,
+// e.g. "L7STS layer 7 bad status (code 503, 12ms): HTTP status check returned code 503"
+func checkFailureReason(b HAProxyBackend) string {
+	check := strings.TrimPrefix(b.CheckStatus, "* ")
+	reason := check
+	if desc, ok := haproxyCheckStatuses[check]; ok {
+		reason += " " + desc
+	}
+
+	var detail []string
+	if b.CheckCode != "" {
+		detail = append(detail, "code "+b.CheckCode)
+	}
+	if b.CheckDuration != "" {
+		detail = append(detail, b.CheckDuration+"ms")
+	}
+	if len(detail) > 0 {
+		reason += " (" + strings.Join(detail, ", ") + ")"
+	}
+	if b.LastCheck != "" {
+		reason += ": " + b.LastCheck
+	}
+	if reason == "" {
+		return "no check detail reported"
+	}
+	return strings.TrimSpace(reason)
+}
+
 func formatDuration(seconds string) string {
 	secs, err := strconv.Atoi(seconds)
 	if err != nil {
@@ -660,16 +1785,24 @@ func formatDuration(seconds string) string {
 	return fmt.Sprintf("%ds", secs)
 }
 
+// proxySQLAdminDSN returns the DSN for the ProxySQL admin interface, using
+// the same TLS settings as the proxy connections
+func proxySQLAdminDSN() string {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s)/",
+		cfg.ProxySQLAdminUser, cfg.ProxySQLAdminPassword,
+		net.JoinHostPort(cfg.ProxySQLAdminHost, strconv.Itoa(cfg.ProxySQLAdminPort)))
+	if tlsDSNParam != "" {
+		dsn += "?tls=" + url.QueryEscape(tlsDSNParam)
+	}
+	return dsn
+}
+
 func printProxySQLStats(ctx context.Context) {
 	bold := color.New(color.Bold)
 	bold.Println("[PROXYSQL STATUS]")
 	fmt.Println(strings.Repeat("-", 79))
 
-	adminDSN := fmt.Sprintf("%s:%s@tcp(%s:%d)/",
-		cfg.ProxySQLAdminUser, cfg.ProxySQLAdminPassword,
-		cfg.ProxySQLAdminHost, cfg.ProxySQLAdminPort)
-
-	adminDB, err := sql.Open("mysql", adminDSN)
+	adminDB, err := sql.Open("mysql", proxySQLAdminDSN())
 	if err != nil {
 		color.Red("  Error connecting to ProxySQL admin: %v", err)
 		fmt.Println()
@@ -678,13 +1811,13 @@ func printProxySQLStats(ctx context.Context) {
 	defer adminDB.Close()
 
 	// Get server status
-	servers, err := fetchProxySQLServers(ctx, adminDB)
+	servers, runtimeOnly, err := refreshProxySQLServers(ctx, adminDB)
 	if err != nil {
 		color.Red("  Error fetching server status: %v", err)
 	} else {
 		fmt.Println("  MySQL Servers:")
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"HG", "Host", "Port", "Status", "Weight", "Max Conn", "Latency"})
+		table.SetHeader([]string{"HG", "Host", "Port", "Status", "Weight", "Max Conn", "Latency", "Runtime"})
 		table.SetBorder(false)
 		table.SetColumnSeparator("|")
 
@@ -706,24 +1839,40 @@ func printProxySQLStats(ctx context.Context) {
 				fmt.Sprintf("%d", s.Weight),
 				fmt.Sprintf("%d", s.MaxConns),
 				fmt.Sprintf("%dms", s.MaxLatencyMs),
+				formatServerDrift(s.Drift),
 			})
 		}
 		table.Render()
+
+		drifted := len(runtimeOnly)
+		for _, s := range servers {
+			if s.Drift != "" {
+				drifted++
+			}
+		}
+		for _, r := range runtimeOnly {
+			color.Red("  HG %d %s:%d is in runtime but not in mysql_servers", r.HostgroupID, r.Hostname, r.Port)
+		}
+		if drifted > 0 {
+			color.Red("  Config not loaded: %d server(s) differ from runtime, run LOAD MYSQL SERVERS TO RUNTIME", drifted)
+		}
 	}
 	fmt.Println()
 
 	// Get connection pool stats
-	poolStats, err := fetchProxySQLConnPool(ctx, adminDB)
+	poolStats, err := refreshProxySQLPool(ctx, adminDB)
 	if err != nil {
 		color.Red("  Error fetching connection pool stats: %v", err)
 	} else {
 		fmt.Println("  Connection Pool Stats:")
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"HG", "Server", "Status", "Used", "Free", "OK", "Err", "Queries", "Latency"})
+		table.SetHeader([]string{"HG", "Server", "Status", "Used", "Free", "OK", "Err", "Queries", "Latency", "Shuns", "Last Change"})
 		table.SetBorder(false)
 		table.SetColumnSeparator("|")
 
 		for _, p := range poolStats {
+			h := proxySQLStatusHistory.Get(p)
+
 			status := p.Status
 			if strings.Contains(p.Status, "ONLINE") {
 				status = color.GreenString(p.Status)
@@ -746,109 +1895,469 @@ func printProxySQLStats(ctx context.Context) {
 				errCount,
 				fmt.Sprintf("%d", p.Queries),
 				fmt.Sprintf("%dus", p.LatencyUs),
+				formatShunCount(h.ShunCount),
+				formatLastChange(h.LastChange),
 			})
 		}
 		table.Render()
+
+		if allProxySQLServersDown(poolStats) {
+			printAllBackendsDownBanner()
+		}
 	}
 	fmt.Println()
+
+	printProxySQLGlobalStats(ctx, adminDB)
+	printProxySQLQueryRules(ctx, adminDB)
 }
 
-func fetchProxySQLServers(ctx context.Context, db *sql.DB) ([]ProxySQLServer, error) {
-	rows, err := db.QueryContext(ctx, `
-		SELECT hostgroup_id, hostname, port, status, weight, compression, max_connections, 
-		       max_latency_ms, comment 
-		FROM mysql_servers
-		ORDER BY hostgroup_id, hostname
-	`)
+// refreshProxySQLServers fetches mysql_servers and its runtime drift within
+// --stats-timeout and stores them in the snapshot
+func refreshProxySQLServers(ctx context.Context, adminDB *sql.DB) ([]ProxySQLServer, []ProxySQLServer, error) {
+	fetchCtx, cancel := statsContext(ctx)
+	servers, runtimeOnly, err := fetchProxySQLServers(fetchCtx, adminDB)
+	cancel()
 	if err != nil {
-		return nil, err
+		err = statsFetchError(err)
 	}
-	defer rows.Close()
+	snapshot.setProxySQLServers(servers, err)
+	return servers, runtimeOnly, err
+}
 
-	var servers []ProxySQLServer
-	for rows.Next() {
-		var s ProxySQLServer
-		if err := rows.Scan(&s.HostgroupID, &s.Hostname, &s.Port, &s.Status, &s.Weight,
-			&s.Compression, &s.MaxConns, &s.MaxLatencyMs, &s.Comment); err != nil {
-			continue
+// refreshProxySQLPool fetches stats_mysql_connection_pool within
+// --stats-timeout, records status transitions and stores it in the snapshot
+func refreshProxySQLPool(ctx context.Context, adminDB *sql.DB) ([]ProxySQLConnPool, error) {
+	fetchCtx, cancel := statsContext(ctx)
+	pool, err := fetchProxySQLConnPool(fetchCtx, adminDB)
+	cancel()
+	if err != nil {
+		err = statsFetchError(err)
+	} else {
+		proxySQLStatusHistory.Track(pool, time.Now())
+	}
+	snapshot.setProxySQLPool(pool, err)
+	return pool, err
+}
+
+// formatServerDrift renders the Runtime column of the MySQL Servers table
+func formatServerDrift(drift string) string {
+	if drift == "" {
+		return color.GreenString("loaded")
+	}
+	return color.RedString("config not loaded (%s)", drift)
+}
+
+func printProxySQLGlobalStats(ctx context.Context, adminDB *sql.DB) {
+	bold := color.New(color.Bold)
+	bold.Println("[PROXYSQL GLOBAL STATS]")
+	fmt.Println(strings.Repeat("-", 79))
+
+	fetchCtx, cancel := statsContext(ctx)
+	global, err := fetchProxySQLGlobalStats(fetchCtx, adminDB)
+	cancel()
+	if err != nil {
+		color.Red("  Error fetching global stats: %v", statsFetchError(err))
+		fmt.Println()
+		return
+	}
+	prev := lastProxySQLGlobal
+	lastProxySQLGlobal = &global
+
+	delta := func(cur func(ProxySQLGlobalStats) int64) string {
+		if prev == nil {
+			return "-"
 		}
-		servers = append(servers, s)
+		return fmt.Sprintf("%+d", cur(global)-cur(*prev))
 	}
-	return servers, nil
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Metric", "Value", "Delta", "Metric", "Value", "Delta"})
+	table.SetBorder(false)
+	table.SetColumnSeparator("|")
+
+	table.Append([]string{
+		"Questions", fmt.Sprintf("%d", global.Questions),
+		delta(func(g ProxySQLGlobalStats) int64 { return g.Questions }),
+		"Slow Queries", fmt.Sprintf("%d", global.SlowQueries),
+		delta(func(g ProxySQLGlobalStats) int64 { return g.SlowQueries }),
+	})
+	table.Append([]string{
+		"Client Conns", fmt.Sprintf("%d", global.ClientConnectionsConnected),
+		delta(func(g ProxySQLGlobalStats) int64 { return g.ClientConnectionsConnected }),
+		"Client Aborted", formatErrorCount(global.ClientConnectionsAborted),
+		delta(func(g ProxySQLGlobalStats) int64 { return g.ClientConnectionsAborted }),
+	})
+	table.Append([]string{
+		"Backend Conns", fmt.Sprintf("%d", global.ServerConnectionsConnected),
+		delta(func(g ProxySQLGlobalStats) int64 { return g.ServerConnectionsConnected }),
+		"Backend Aborted", formatErrorCount(global.ServerConnectionsAborted),
+		delta(func(g ProxySQLGlobalStats) int64 { return g.ServerConnectionsAborted }),
+	})
+	table.Append([]string{
+		"Active Trx", fmt.Sprintf("%d", global.ActiveTransactions),
+		delta(func(g ProxySQLGlobalStats) int64 { return g.ActiveTransactions }),
+		"Memory (pool/qc)", fmt.Sprintf("%s / %s", formatBytes(global.ConnPoolMemoryBytes), formatBytes(global.QueryCacheMemoryBytes)),
+		"",
+	})
+	table.Render()
+	fmt.Println()
 }
 
-func fetchProxySQLConnPool(ctx context.Context, db *sql.DB) ([]ProxySQLConnPool, error) {
-	rows, err := db.QueryContext(ctx, `
-		SELECT hostgroup, srv_host, srv_port, status, ConnUsed, ConnFree, ConnOK, ConnERR,
-		       Queries, Bytes_data_sent, Bytes_data_recv, Latency_us
-		FROM stats_mysql_connection_pool
-		ORDER BY hostgroup, srv_host
-	`)
+func fetchProxySQLGlobalStats(ctx context.Context, db *sql.DB) (ProxySQLGlobalStats, error) {
+	rows, err := db.QueryContext(ctx, "SELECT Variable_Name, Variable_Value FROM stats_mysql_global")
 	if err != nil {
-		return nil, err
+		return ProxySQLGlobalStats{}, err
 	}
 	defer rows.Close()
 
-	var pools []ProxySQLConnPool
+	vars := make(map[string]string)
 	for rows.Next() {
-		var p ProxySQLConnPool
-		if err := rows.Scan(&p.HostgroupID, &p.SrvHost, &p.SrvPort, &p.Status,
-			&p.ConnUsed, &p.ConnFree, &p.ConnOK, &p.ConnErr, &p.Queries,
-			&p.BytesDataSent, &p.BytesDataRecv, &p.LatencyUs); err != nil {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
 			continue
 		}
-		pools = append(pools, p)
+		vars[name] = value
 	}
-	return pools, nil
+	return parseProxySQLGlobalStats(vars), nil
 }
 
-func printPXCStatus(ctx context.Context) {
+// parseProxySQLGlobalStats maps stats_mysql_global name/value pairs onto
+// ProxySQLGlobalStats. Missing or non-numeric values are left at zero.
+func parseProxySQLGlobalStats(vars map[string]string) ProxySQLGlobalStats {
+	get := func(name string) int64 {
+		v, _ := strconv.ParseInt(vars[name], 10, 64)
+		return v
+	}
+
+	return ProxySQLGlobalStats{
+		Questions:                  get("Questions"),
+		SlowQueries:                get("Slow_queries"),
+		ActiveTransactions:         get("Active_Transactions"),
+		ClientConnectionsConnected: get("Client_Connections_connected"),
+		ClientConnectionsAborted:   get("Client_Connections_aborted"),
+		ServerConnectionsConnected: get("Server_Connections_connected"),
+		ServerConnectionsAborted:   get("Server_Connections_aborted"),
+		ConnPoolMemoryBytes:        get("ConnPool_memory_bytes"),
+		QueryCacheMemoryBytes:      get("Query_Cache_Memory_bytes"),
+	}
+}
+
+func formatBytes(b int64) string {
+	switch {
+	case b >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(b)/(1<<30))
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1fM", float64(b)/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1fK", float64(b)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", b)
+}
+
+func printProxySQLQueryRules(ctx context.Context, adminDB *sql.DB) {
 	bold := color.New(color.Bold)
-	bold.Println("[PXC CLUSTER STATUS]")
+	bold.Println("[PROXYSQL QUERY RULES]")
 	fmt.Println(strings.Repeat("-", 79))
 
-	if len(cfg.PXCNodes) == 0 {
-		color.Yellow("  No PXC nodes configured. Use --pxc-nodes to specify nodes.")
+	fetchCtx, cancel := statsContext(ctx)
+	rules, err := fetchProxySQLQueryRules(fetchCtx, adminDB)
+	cancel()
+	if err != nil {
+		color.Red("  Error fetching query rules: %v", statsFetchError(err))
 		fmt.Println()
 		return
 	}
 
-	var wg sync.WaitGroup
-	statusCh := make(chan PXCNodeStatus, len(cfg.PXCNodes))
+	if len(rules) == 0 {
+		color.Yellow("  No query rules defined - all traffic goes to the user's default hostgroup")
+		fmt.Println()
+		return
+	}
 
-	for _, node := range cfg.PXCNodes {
-		wg.Add(1)
-		go func(nodeAddr string) {
-			defer wg.Done()
-			status, err := fetchPXCNodeStatus(ctx, nodeAddr)
-			if err != nil {
-				statusCh <- PXCNodeStatus{
-					Address:       nodeAddr,
-					ClusterStatus: color.RedString("ERROR: %v", err),
-				}
-				return
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Rule", "Active", "Match Pattern", "Dest HG", "Apply"})
+	table.SetBorder(false)
+	table.SetColumnSeparator("|")
+	table.SetColWidth(40)
+
+	for _, r := range rules {
+		active := color.GreenString("yes")
+		if r.Active == 0 {
+			active = color.YellowString("no")
+		}
+
+		destHG := "-"
+		if r.DestinationHostgroup.Valid {
+			destHG = fmt.Sprintf("%d", r.DestinationHostgroup.Int64)
+		}
+
+		apply := "no"
+		if r.Apply != 0 {
+			apply = "yes"
+		}
+
+		table.Append([]string{
+			fmt.Sprintf("%d", r.RuleID),
+			active,
+			r.MatchPattern,
+			destHG,
+			apply,
+		})
+	}
+	table.Render()
+	fmt.Println()
+}
+
+// fetchProxySQLServers reads the mysql_servers config and flags each row that
+// differs from runtime_mysql_servers, i.e. wasn't loaded to runtime yet
+func fetchProxySQLServers(ctx context.Context, db *sql.DB) ([]ProxySQLServer, []ProxySQLServer, error) {
+	servers, err := queryProxySQLServers(ctx, db, "mysql_servers")
+	if err != nil {
+		return nil, nil, err
+	}
+	runtimeServers, err := queryProxySQLServers(ctx, db, "runtime_mysql_servers")
+	if err != nil {
+		return nil, nil, fmt.Errorf("runtime_mysql_servers: %w", err)
+	}
+	runtimeOnly := markProxySQLServerDrift(servers, runtimeServers)
+	return servers, runtimeOnly, nil
+}
+
+func queryProxySQLServers(ctx context.Context, db *sql.DB, table string) ([]ProxySQLServer, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT hostgroup_id, hostname, port, status, weight, compression, max_connections, 
+		       max_latency_ms, comment 
+		FROM `+table+`
+		ORDER BY hostgroup_id, hostname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var servers []ProxySQLServer
+	for rows.Next() {
+		var s ProxySQLServer
+		if err := rows.Scan(&s.HostgroupID, &s.Hostname, &s.Port, &s.Status, &s.Weight,
+			&s.Compression, &s.MaxConns, &s.MaxLatencyMs, &s.Comment); err != nil {
+			continue
+		}
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+func proxySQLServerAddrKey(s ProxySQLServer) string {
+	return fmt.Sprintf("%d/%s", s.HostgroupID, net.JoinHostPort(s.Hostname, strconv.Itoa(s.Port)))
+}
+
+// markProxySQLServerDrift sets Drift on every config row whose runtime copy
+// is missing or has different settings, the result of editing mysql_servers
+// without LOAD MYSQL SERVERS TO RUNTIME. It returns the runtime rows with no
+// config row, e.g. a server deleted from the config but still routed to.
+func markProxySQLServerDrift(config, runtimeServers []ProxySQLServer) []ProxySQLServer {
+	byKey := make(map[string]ProxySQLServer, len(runtimeServers))
+	for _, r := range runtimeServers {
+		byKey[proxySQLServerAddrKey(r)] = r
+	}
+
+	for i := range config {
+		c := &config[i]
+		key := proxySQLServerAddrKey(*c)
+		r, ok := byKey[key]
+		if !ok {
+			c.Drift = "not in runtime"
+			continue
+		}
+		delete(byKey, key)
+
+		var diffs []string
+		// ProxySQL reports SHUNNED in runtime on its own, that's not drift
+		if r.Status != c.Status && r.Status != "SHUNNED" {
+			diffs = append(diffs, fmt.Sprintf("status %s", r.Status))
+		}
+		if r.Weight != c.Weight {
+			diffs = append(diffs, fmt.Sprintf("weight %d", r.Weight))
+		}
+		if r.MaxConns != c.MaxConns {
+			diffs = append(diffs, fmt.Sprintf("max conn %d", r.MaxConns))
+		}
+		if r.MaxLatencyMs != c.MaxLatencyMs {
+			diffs = append(diffs, fmt.Sprintf("latency %dms", r.MaxLatencyMs))
+		}
+		if r.Compression != c.Compression {
+			diffs = append(diffs, fmt.Sprintf("compression %d", r.Compression))
+		}
+		if len(diffs) > 0 {
+			c.Drift = "runtime " + strings.Join(diffs, ", ")
+		}
+	}
+
+	var runtimeOnly []ProxySQLServer
+	for _, r := range runtimeServers {
+		if _, ok := byKey[proxySQLServerAddrKey(r)]; ok {
+			runtimeOnly = append(runtimeOnly, r)
+		}
+	}
+	return runtimeOnly
+}
+
+// ServerStatusHistory tracks a ProxySQL backend's status across refreshes, so
+// a node that flaps between ONLINE and SHUNNED stands out even when it
+// happens to be ONLINE right now
+type ServerStatusHistory struct {
+	Status     string
+	LastChange time.Time // zero until a transition has been observed
+	ShunCount  int
+}
+
+// ProxySQLStatusTracker keeps a ServerStatusHistory per backend, keyed by
+// hostgroup and address. SIGUSR1 resets it from another goroutine.
+type ProxySQLStatusTracker struct {
+	mu      sync.Mutex
+	servers map[string]*ServerStatusHistory
+}
+
+func proxySQLServerKey(p ProxySQLConnPool) string {
+	return fmt.Sprintf("%d/%s", p.HostgroupID, net.JoinHostPort(p.SrvHost, strconv.Itoa(p.SrvPort)))
+}
+
+// Track records status transitions seen in stats_mysql_connection_pool,
+// counting every move into SHUNNED
+func (t *ProxySQLStatusTracker) Track(pools []ProxySQLConnPool, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.servers == nil {
+		t.servers = make(map[string]*ServerStatusHistory)
+	}
+	for _, p := range pools {
+		key := proxySQLServerKey(p)
+		h, ok := t.servers[key]
+		if !ok {
+			h = &ServerStatusHistory{Status: p.Status}
+			if p.Status == "SHUNNED" {
+				h.ShunCount = 1
 			}
-			statusCh <- status
-		}(node)
+			t.servers[key] = h
+			continue
+		}
+		if h.Status == p.Status {
+			continue
+		}
+		if p.Status == "SHUNNED" {
+			h.ShunCount++
+		}
+		h.Status = p.Status
+		h.LastChange = now
 	}
+}
 
-	go func() {
-		wg.Wait()
-		close(statusCh)
-	}()
+// Get returns the history of p's backend, zero if it hasn't been tracked
+func (t *ProxySQLStatusTracker) Get(p ProxySQLConnPool) ServerStatusHistory {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	var statuses []PXCNodeStatus
-	for s := range statusCh {
-		statuses = append(statuses, s)
+	if h, ok := t.servers[proxySQLServerKey(p)]; ok {
+		return *h
 	}
+	return ServerStatusHistory{}
+}
 
-	// Sort by address for consistent display
-	sort.Slice(statuses, func(i, j int) bool {
-		return statuses[i].Address < statuses[j].Address
-	})
+// Reset forgets every backend's history, e.g. after the stats are zeroed
+func (t *ProxySQLStatusTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.servers = nil
+}
+
+func formatShunCount(n int) string {
+	if n > 0 {
+		return color.RedString("%d", n)
+	}
+	return "0"
+}
+
+// formatLastChange shows how long ago a backend last changed status
+func formatLastChange(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	ago := time.Since(t).Round(time.Second)
+	if ago < time.Minute {
+		return color.YellowString("%s ago", ago)
+	}
+	return fmt.Sprintf("%s ago", ago)
+}
+
+func fetchProxySQLConnPool(ctx context.Context, db *sql.DB) ([]ProxySQLConnPool, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT hostgroup, srv_host, srv_port, status, ConnUsed, ConnFree, ConnOK, ConnERR,
+		       Queries, Bytes_data_sent, Bytes_data_recv, Latency_us
+		FROM stats_mysql_connection_pool
+		ORDER BY hostgroup, srv_host
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pools []ProxySQLConnPool
+	for rows.Next() {
+		var p ProxySQLConnPool
+		if err := rows.Scan(&p.HostgroupID, &p.SrvHost, &p.SrvPort, &p.Status,
+			&p.ConnUsed, &p.ConnFree, &p.ConnOK, &p.ConnErr, &p.Queries,
+			&p.BytesDataSent, &p.BytesDataRecv, &p.LatencyUs); err != nil {
+			continue
+		}
+		pools = append(pools, p)
+	}
+	return pools, nil
+}
+
+func fetchProxySQLQueryRules(ctx context.Context, db *sql.DB) ([]ProxySQLQueryRule, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT rule_id, active, COALESCE(match_pattern, match_digest, ''), destination_hostgroup, apply
+		FROM mysql_query_rules
+		ORDER BY rule_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []ProxySQLQueryRule
+	for rows.Next() {
+		var r ProxySQLQueryRule
+		if err := rows.Scan(&r.RuleID, &r.Active, &r.MatchPattern, &r.DestinationHostgroup, &r.Apply); err != nil {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func printPXCStatus(ctx context.Context) {
+	bold := color.New(color.Bold)
+	bold.Println("[PXC CLUSTER STATUS]")
+	fmt.Println(strings.Repeat("-", 79))
+
+	if len(cfg.PXCNodes) == 0 {
+		color.Yellow("  No PXC nodes configured. Use --pxc-nodes to specify nodes.")
+		fmt.Println()
+		return
+	}
+
+	statuses := refreshPXC(ctx)
+
+	header := []string{"Node", "State", "Cluster", "Size", "Ready", "Flow Ctrl", "Recv Q", "Send Q", "Conns"}
+	if cfg.ProbeNodes {
+		header = append(header, "Probe")
+	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Node", "State", "Cluster", "Size", "Ready", "Flow Ctrl", "Recv Q", "Send Q", "Conns"})
+	table.SetHeader(header)
 	table.SetBorder(false)
 	table.SetColumnSeparator("|")
 
@@ -870,38 +2379,313 @@ func printPXCStatus(ctx context.Context) {
 		}
 
 		fc := s.FlowControl
-		if s.FlowControl == "OFF" || s.FlowControl == "0" {
+		if h := flowControlHistory.Get(s.Address); len(h.Fractions) > 0 {
+			fc = formatFlowControl(&h)
+		} else if s.FlowControl == "OFF" || s.FlowControl == "0" {
 			fc = color.GreenString(s.FlowControl)
 		} else if s.FlowControl != "" {
 			fc = color.YellowString(s.FlowControl)
 		}
 
-		table.Append([]string{
+		cluster := s.ClusterStatus
+		if strings.HasPrefix(cluster, "ERROR") {
+			cluster = color.RedString(cluster)
+		}
+
+		row := []string{
 			s.NodeName,
 			state,
-			s.ClusterStatus,
+			cluster,
 			fmt.Sprintf("%d", s.ClusterSize),
 			ready,
 			fc,
 			fmt.Sprintf("%d", s.RecvQueue),
 			fmt.Sprintf("%d", s.SendQueue),
-			fmt.Sprintf("%d", s.Connections),
-		})
+			formatNodeConnections(s),
+		}
+		if cfg.ProbeNodes {
+			row = append(row, formatProbeLatency(s))
+		}
+		table.Append(row)
 	}
 	table.Render()
 	fmt.Println()
+
+	printCatchUp(statuses)
+}
+
+// refreshPXC fetches every --pxc-nodes entry in parallel, each within
+// --stats-timeout, and stores the statuses in the snapshot sorted by address
+func refreshPXC(ctx context.Context) []PXCNodeStatus {
+	var wg sync.WaitGroup
+	statusCh := make(chan PXCNodeStatus, len(cfg.PXCNodes))
+
+	for _, node := range cfg.PXCNodes {
+		wg.Add(1)
+		go func(nodeAddr string) {
+			defer wg.Done()
+			fetchCtx, cancel := statsContext(ctx)
+			defer cancel()
+			status, err := fetchPXCNodeStatus(fetchCtx, nodeAddr)
+			if err != nil {
+				statusCh <- PXCNodeStatus{
+					Address:       nodeAddr,
+					ClusterStatus: fmt.Sprintf("ERROR: %v", statsFetchError(err)),
+				}
+				return
+			}
+			statusCh <- status
+		}(node)
+	}
+
+	go func() {
+		wg.Wait()
+		close(statusCh)
+	}()
+
+	statuses := make([]PXCNodeStatus, 0, len(cfg.PXCNodes))
+	for s := range statusCh {
+		statuses = append(statuses, s)
+	}
+
+	// Sort by address for consistent display
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Address < statuses[j].Address
+	})
+	flowControlHistory.Track(statuses, time.Now())
+	snapshot.setPXC(statuses)
+	return statuses
+}
+
+// flowControlHistorySize is how many refreshes of flow-control history are
+// kept per node
+const flowControlHistorySize = 10
+
+// FlowControlHistory is a small ring of recent per-refresh flow-control pause
+// fractions for one node, derived from the cumulative
+// wsrep_flow_control_paused_ns counter
+type FlowControlHistory struct {
+	Fractions  []float64 // oldest first, at most flowControlHistorySize
+	NonZeroRun int       // consecutive refreshes with a nonzero pause
+
+	lastPausedNs int64
+	lastSample   time.Time
+}
+
+// FlowControlTracker keeps a FlowControlHistory per node address. refreshPXC
+// feeds it from whichever loop is refreshing, and the status page reads it
+// from the HTTP server's goroutines.
+type FlowControlTracker struct {
+	mu    sync.Mutex
+	nodes map[string]*FlowControlHistory
+}
+
+var flowControlHistory FlowControlTracker
+
+// Track adds each node's cumulative wsrep_flow_control_paused_ns, skipping
+// nodes whose status couldn't be fetched
+func (t *FlowControlTracker) Track(statuses []PXCNodeStatus, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.nodes == nil {
+		t.nodes = make(map[string]*FlowControlHistory)
+	}
+	for _, s := range statuses {
+		pausedNs, err := strconv.ParseInt(s.FlowControl, 10, 64)
+		if err != nil {
+			continue
+		}
+		h, ok := t.nodes[s.Address]
+		if !ok {
+			h = &FlowControlHistory{}
+			t.nodes[s.Address] = h
+		}
+		h.Add(pausedNs, now)
+	}
+}
+
+// Get returns a copy of a node's history, zero if it hasn't been tracked
+func (t *FlowControlTracker) Get(addr string) FlowControlHistory {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.nodes[addr]
+	if !ok {
+		return FlowControlHistory{}
+	}
+	c := *h
+	c.Fractions = append([]float64(nil), h.Fractions...)
+	return c
+}
+
+// Reset forgets every node's history
+func (t *FlowControlTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nodes = nil
+}
+
+// Add records a new cumulative paused_ns sample. The first sample only sets
+// the baseline; each later one appends the fraction of wall time paused
+// since the previous sample.
+func (h *FlowControlHistory) Add(pausedNs int64, at time.Time) {
+	defer func() {
+		h.lastPausedNs = pausedNs
+		h.lastSample = at
+	}()
+
+	if h.lastSample.IsZero() {
+		return
+	}
+	elapsed := at.Sub(h.lastSample)
+	delta := pausedNs - h.lastPausedNs
+	// Counter reset (FLUSH STATUS or node restart) or clock oddity
+	if elapsed <= 0 || delta < 0 {
+		return
+	}
+
+	fraction := float64(delta) / float64(elapsed.Nanoseconds())
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	h.Fractions = append(h.Fractions, fraction)
+	if len(h.Fractions) > flowControlHistorySize {
+		h.Fractions = h.Fractions[len(h.Fractions)-flowControlHistorySize:]
+	}
+
+	if fraction > 0 {
+		h.NonZeroRun++
+	} else {
+		h.NonZeroRun = 0
+	}
+}
+
+// Trend compares the latest fraction with the mean of the earlier ones
+func (h *FlowControlHistory) Trend() string {
+	if len(h.Fractions) < 2 {
+		return "→"
+	}
+	latest := h.Fractions[len(h.Fractions)-1]
+	var sum float64
+	for _, f := range h.Fractions[:len(h.Fractions)-1] {
+		sum += f
+	}
+	mean := sum / float64(len(h.Fractions)-1)
+
+	const epsilon = 0.001
+	switch {
+	case latest > mean+epsilon:
+		return "↑"
+	case latest < mean-epsilon:
+		return "↓"
+	}
+	return "→"
+}
+
+// Sustained reports whether flow control has paused the node for at least
+// n consecutive refreshes
+func (h *FlowControlHistory) Sustained(n int) bool {
+	return n > 0 && h.NonZeroRun >= n
+}
+
+// Text is the latest pause fraction and its trend, e.g. "5.0% ↑"
+func (h *FlowControlHistory) Text() string {
+	return fmt.Sprintf("%.1f%% %s", h.Fractions[len(h.Fractions)-1]*100, h.Trend())
+}
+
+// Level rates the history as ok (no pause), warn (paused on the latest
+// refresh) or bad (paused for --fc-sustained-refreshes in a row)
+func (h *FlowControlHistory) Level() string {
+	switch {
+	case h.Sustained(cfg.FCSustainedRefreshes):
+		return "bad"
+	case h.Fractions[len(h.Fractions)-1] > 0:
+		return "warn"
+	}
+	return "ok"
+}
+
+func formatFlowControl(h *FlowControlHistory) string {
+	switch h.Level() {
+	case "bad":
+		return color.RedString(h.Text())
+	case "warn":
+		return color.YellowString(h.Text())
+	}
+	return color.GreenString(h.Text())
+}
+
+// pxcNodeDBs holds a single-connection pool per --pxc-nodes entry, kept
+// open across refreshes so the status queries and the --probe-nodes timing
+// don't pay for a new connection and authentication every time
+var (
+	pxcNodeDBsMu sync.Mutex
+	pxcNodeDBs   = make(map[string]*sql.DB)
+)
+
+// pxcNodeDB returns the pool for a PXC node, opening it on first use
+func pxcNodeDB(nodeAddr string) (*sql.DB, error) {
+	pxcNodeDBsMu.Lock()
+	defer pxcNodeDBsMu.Unlock()
+
+	if db, ok := pxcNodeDBs[nodeAddr]; ok {
+		return db, nil
+	}
+	db, err := sql.Open("mysql", pxcNodeDSN(nodeAddr))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	pxcNodeDBs[nodeAddr] = db
+	return db, nil
+}
+
+// pxcNodeDSN is the DSN for a PXC node. The dial timeout follows
+// --stats-timeout so a node that doesn't answer can't outlast the fetch.
+func pxcNodeDSN(nodeAddr string) string {
+	return fmt.Sprintf("%s:%s@tcp(%s)/?timeout=%s", cfg.PXCUser, cfg.PXCPassword, nodeAddr, cfg.StatsTimeout)
+}
+
+// closePXCNodeDBs closes every PXC node pool
+func closePXCNodeDBs() {
+	pxcNodeDBsMu.Lock()
+	defer pxcNodeDBsMu.Unlock()
+
+	for addr, db := range pxcNodeDBs {
+		db.Close()
+		delete(pxcNodeDBs, addr)
+	}
 }
 
 func fetchPXCNodeStatus(ctx context.Context, nodeAddr string) (PXCNodeStatus, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/?timeout=5s", cfg.PXCUser, cfg.PXCPassword, nodeAddr)
-	db, err := sql.Open("mysql", dsn)
+	db, err := pxcNodeDB(nodeAddr)
 	if err != nil {
 		return PXCNodeStatus{}, err
 	}
-	defer db.Close()
 
 	status := PXCNodeStatus{Address: nodeAddr}
 
+	// Time a trivial query directly against the node, bypassing the proxy.
+	// The ping first (re)establishes the pool's connection so only the
+	// query round trip is timed.
+	if cfg.ProbeNodes {
+		var one int
+		if err := db.PingContext(ctx); err != nil {
+			status.ProbeError = err.Error()
+		} else {
+			probeStart := time.Now()
+			if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+				status.ProbeError = err.Error()
+			} else {
+				status.ProbeLatency = time.Since(probeStart)
+			}
+		}
+	}
+
 	// Get hostname
 	db.QueryRowContext(ctx, "SELECT @@hostname").Scan(&status.NodeName)
 
@@ -929,13 +2713,185 @@ func fetchPXCNodeStatus(ctx context.Context, nodeAddr string) (PXCNodeStatus, er
 	status.RecvQueue, _ = strconv.Atoi(wsrepStatus["wsrep_local_recv_queue"])
 	status.SendQueue, _ = strconv.Atoi(wsrepStatus["wsrep_local_send_queue"])
 	status.FlowControl = wsrepStatus["wsrep_flow_control_paused_ns"]
+	status.LastCommitted, _ = strconv.ParseInt(wsrepStatus["wsrep_last_committed"], 10, 64)
+	status.CertDepsDistance, _ = strconv.ParseFloat(wsrepStatus["wsrep_cert_deps_distance"], 64)
 
-	// Get connection count
+	// Get connection count and the limit it runs into
 	db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.processlist").Scan(&status.Connections)
+	db.QueryRowContext(ctx, "SELECT @@max_connections").Scan(&status.MaxConnections)
 
 	return status, nil
 }
 
+// nearMaxConnections reports whether a node's connection count reached
+// --max-connections-warn percent of its max_connections
+func nearMaxConnections(s PXCNodeStatus) bool {
+	if cfg.MaxConnectionsWarn == 0 || s.MaxConnections <= 0 {
+		return false
+	}
+	return s.Connections*100 >= s.MaxConnections*cfg.MaxConnectionsWarn
+}
+
+// formatNodeConnections shows a node's connections as used/max, red when it
+// is close to refusing new ones with "Too many connections"
+func formatNodeConnections(s PXCNodeStatus) string {
+	if s.MaxConnections <= 0 {
+		return fmt.Sprintf("%d", s.Connections)
+	}
+	conns := fmt.Sprintf("%d/%d", s.Connections, s.MaxConnections)
+	if nearMaxConnections(s) {
+		return color.RedString(conns)
+	}
+	return conns
+}
+
+// formatProbeLatency colors a node's direct SELECT 1 latency so a single
+// slow node stands out from its peers
+func formatProbeLatency(s PXCNodeStatus) string {
+	switch {
+	case s.ProbeError != "":
+		return color.RedString("error")
+	case s.ProbeLatency == 0:
+		return "-"
+	case s.ProbeLatency > 100*time.Millisecond:
+		return color.RedString(s.ProbeLatency.Round(time.Microsecond).String())
+	case s.ProbeLatency > 20*time.Millisecond:
+		return color.YellowString(s.ProbeLatency.Round(time.Microsecond).String())
+	}
+	return color.GreenString(s.ProbeLatency.Round(time.Microsecond).String())
+}
+
+// writeNodeCount returns how many distinct backend servers have served
+// writes. Writes whose backend couldn't be identified aren't counted, so a
+// failed lookup can't look like a second writer. Callers must hold stats.mu.
+func writeNodeCount() int {
+	n := 0
+	for id, r := range stats.Routing {
+		if id != unknownServerID && r.Writes > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// sortedRouting returns the routing entries ordered by node name, then
+// server id. Callers must hold stats.mu.
+func sortedRouting() []NodeRouting {
+	routes := make([]NodeRouting, 0, len(stats.Routing))
+	for _, r := range stats.Routing {
+		routes = append(routes, *r)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Node != routes[j].Node {
+			return routes[i].Node < routes[j].Node
+		}
+		return routes[i].ServerID < routes[j].ServerID
+	})
+	return routes
+}
+
+// sharePercent formats n as a percentage of total, "-" when total is zero
+func sharePercent(n, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)/float64(total)*100)
+}
+
+// formatServerID shows a routing entry's server id, "?" when unidentified
+func formatServerID(id int64) string {
+	if id == unknownServerID {
+		return "?"
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+func printRoutingStats() {
+	stats.mu.RLock()
+	defer stats.mu.RUnlock()
+
+	if len(stats.Routing) == 0 {
+		return
+	}
+
+	bold := color.New(color.Bold)
+	bold.Println("[READ/WRITE ROUTING]")
+	fmt.Println(strings.Repeat("-", 79))
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Backend", "Server ID", "Reads", "Read %", "Writes", "Write %"})
+	table.SetBorder(false)
+	table.SetColumnSeparator("|")
+
+	var totalReads, totalWrites int64
+	for _, r := range stats.Routing {
+		totalReads += r.Reads
+		totalWrites += r.Writes
+	}
+
+	for _, r := range sortedRouting() {
+		table.Append([]string{
+			r.Node,
+			formatServerID(r.ServerID),
+			fmt.Sprintf("%d", r.Reads),
+			sharePercent(r.Reads, totalReads),
+			fmt.Sprintf("%d", r.Writes),
+			sharePercent(r.Writes, totalWrites),
+		})
+	}
+	table.Render()
+
+	// Single-primary routing should send every write to the same node
+	if n := writeNodeCount(); n > 1 {
+		color.Red("  WRITE SPLIT VIOLATION: writes landed on %d nodes (expected 1 for single-primary routing)", n)
+	}
+	fmt.Println()
+}
+
+func printErrorBreakdown() {
+	stats.mu.RLock()
+	defer stats.mu.RUnlock()
+
+	if len(stats.ErrorCategories) == 0 {
+		return
+	}
+
+	bold := color.New(color.Bold)
+	bold.Println("[ERROR BREAKDOWN]")
+	fmt.Println(strings.Repeat("-", 79))
+
+	categories := make([]string, 0, len(stats.ErrorCategories))
+	var total int64
+	for category, count := range stats.ErrorCategories {
+		categories = append(categories, category)
+		total += count
+	}
+	// Most frequent first, name as tie-breaker for a stable display
+	sort.Slice(categories, func(i, j int) bool {
+		ci, cj := stats.ErrorCategories[categories[i]], stats.ErrorCategories[categories[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return categories[i] < categories[j]
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Category", "Count", "Share"})
+	table.SetBorder(false)
+	table.SetColumnSeparator("|")
+
+	for _, category := range categories {
+		count := stats.ErrorCategories[category]
+		table.Append([]string{
+			category,
+			color.RedString("%d", count),
+			fmt.Sprintf("%.1f%%", float64(count)/float64(total)*100),
+		})
+	}
+	table.Render()
+	fmt.Println()
+}
+
 func printConnectionErrors() {
 	stats.mu.RLock()
 	defer stats.mu.RUnlock()
@@ -976,9 +2932,14 @@ func printConnectionErrors() {
 	fmt.Println()
 }
 
-func printFooter() {
+func printFooter(pools []*ProxyPool) {
+	targets := make([]string, 0, len(pools))
+	for _, p := range pools {
+		targets = append(targets, p.Addr)
+	}
+
 	fmt.Println(strings.Repeat("=", 79))
-	color.Cyan("  Press Ctrl+C to exit | Refresh: 2s | Target: %s:%d", cfg.ProxyHost, cfg.ProxyPort)
+	color.Cyan("  Press Ctrl+C to exit | Refresh: 2s | Target: %s", strings.Join(targets, ", "))
 
 	stats.mu.RLock()
 	errorRate := float64(0)
@@ -986,6 +2947,9 @@ func printFooter() {
 	if total > 0 {
 		errorRate = float64(stats.FailedReads+stats.FailedWrites) / float64(total) * 100
 	}
+	reconnects := stats.ReconnectAttempts
+	checks, staleReads := stats.ConsistencyChecks, stats.StaleReads
+	lastStale, lastStaleNode := stats.LastStaleRead, stats.LastStaleReadNode
 	stats.mu.RUnlock()
 
 	if errorRate > 0 {
@@ -993,6 +2957,25 @@ func printFooter() {
 	} else {
 		color.Green("  ERROR RATE: 0%% - All connections healthy")
 	}
+
+	for _, p := range pools {
+		if failures, backoff := p.Backoff.State(); failures > 0 {
+			color.Yellow("  RECONNECT BACKOFF: %s - %d consecutive failures, waiting %s between attempts",
+				p.Addr, failures, backoff.Round(time.Millisecond))
+		}
+	}
+	if reconnects > 0 {
+		fmt.Printf("  Reconnect attempts: %d\n", reconnects)
+	}
+
+	if cfg.ConsistencyCheck {
+		if staleReads > 0 {
+			color.Red("  STALE READS: %d of %d read-after-write checks (last %s on %s)",
+				staleReads, checks, lastStale.Format("15:04:05"), lastStaleNode)
+		} else {
+			color.Green("  STALE READS: 0 of %d read-after-write checks", checks)
+		}
+	}
 }
 
 // Atomic counters for high-frequency updates
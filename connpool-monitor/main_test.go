@@ -0,0 +1,1769 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/go-sql-driver/mysql"
+)
+
+// resetGlobals gives a test a zero cfg and stats and restores them afterwards
+func resetGlobals(t *testing.T) {
+	t.Helper()
+	saved := cfg
+	cfg = Config{}
+	stats = ConnectionStats{}
+	snapshot = DashboardSnapshot{}
+	t.Cleanup(func() {
+		cfg = saved
+		stats = ConnectionStats{}
+		snapshot = DashboardSnapshot{}
+	})
+}
+
+// captureStdout returns what fn prints, including color output, which
+// fatih/color writes to the stdout it saw at init
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedStdout, savedOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+
+	defer func() {
+		os.Stdout, color.Output = savedStdout, savedOutput
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+const haproxyCSVHeader = "# pxname,svname,status,addr,scur,slim,stot,check_status,lastchg,check_code,check_duration,last_chk\n"
+
+// haproxyStatsServer serves csv as HAProxy's ;csv stats page
+func haproxyStatsServer(t *testing.T, csv string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, csv)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// proxyHandler answers the workload's statements as if every connection
+// landed on node
+func proxyHandler(node string, serverID int64) fakeHandler {
+	return func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		switch {
+		case strings.Contains(query, "CONNECTION_ID()"):
+			return scalar(connID)
+		case strings.Contains(query, "@@server_id"):
+			return fakeResult{columns: []string{"node", "server_id"}, rows: [][]driver.Value{{node, serverID}}}
+		case strings.HasPrefix(query, "SELECT id, data"):
+			return fakeResult{columns: []string{"id", "data"}}
+		case strings.HasPrefix(query, "INSERT"):
+			return fakeResult{lastInsertID: 42}
+		}
+		return fakeResult{err: fmt.Errorf("unexpected query %q", query)}
+	}
+}
+
+// newTestPool wraps db as a proxy endpoint pool
+func newTestPool(addr string, db *sql.DB) *ProxyPool {
+	return &ProxyPool{Addr: addr, DB: db, Backoff: &ReconnectBackoff{}, Consistency: &ConsistencyChecker{}}
+}
+
+func TestRecordLatencyMinMax(t *testing.T) {
+	resetGlobals(t)
+
+	for _, ms := range []int{40, 15, 90, 25} {
+		recordRead(time.Duration(ms)*time.Millisecond, "pxc-0", 1)
+	}
+	for _, ms := range []int{7, 3, 12} {
+		recordWrite(time.Duration(ms)*time.Millisecond, "pxc-0", 1)
+	}
+
+	if stats.MinReadLatency != 15*time.Millisecond || stats.MaxReadLatency != 90*time.Millisecond {
+		t.Errorf("read min/max = %s/%s, want 15ms/90ms", stats.MinReadLatency, stats.MaxReadLatency)
+	}
+	if stats.MinWriteLatency != 3*time.Millisecond || stats.MaxWriteLatency != 12*time.Millisecond {
+		t.Errorf("write min/max = %s/%s, want 3ms/12ms", stats.MinWriteLatency, stats.MaxWriteLatency)
+	}
+	if stats.LastReadLatency != 25*time.Millisecond {
+		t.Errorf("last read = %s, want 25ms", stats.LastReadLatency)
+	}
+}
+
+func TestAllHAProxyBackendsDown(t *testing.T) {
+	backends := func(statuses ...string) []HAProxyBackend {
+		var out []HAProxyBackend
+		for _, s := range statuses {
+			out = append(out, HAProxyBackend{Status: s})
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		statuses []string
+		want     bool
+	}{
+		{[]string{"DOWN", "DOWN", "MAINT"}, true},
+		{[]string{"DOWN", "MAINT (resolution)"}, true},
+		{[]string{"DOWN", "UP"}, false},
+		{[]string{"DOWN", "UP 1/3"}, false},
+		{[]string{"DOWN", "DOWN 1/2"}, false},
+		{[]string{"DOWN", "no check"}, false},
+		{[]string{"DOWN", "NOLB"}, false},
+		{[]string{"MAINT", "DRAIN"}, false},
+		{nil, false},
+	} {
+		if got := allHAProxyBackendsDown(backends(tc.statuses...)); got != tc.want {
+			t.Errorf("%v: got %v, want %v", tc.statuses, got, tc.want)
+		}
+	}
+}
+
+func TestAllProxySQLServersDown(t *testing.T) {
+	down := []ProxySQLConnPool{
+		{HostgroupID: 10, SrvHost: "pxc-0", Status: "SHUNNED"},
+		{HostgroupID: 10, SrvHost: "pxc-1", Status: "OFFLINE_HARD"},
+		{HostgroupID: 20, SrvHost: "pxc-2", Status: "OFFLINE_SOFT"},
+	}
+	if !allProxySQLServersDown(down) {
+		t.Error("all SHUNNED/OFFLINE: expected down")
+	}
+
+	up := append(down, ProxySQLConnPool{HostgroupID: 20, SrvHost: "pxc-1", Status: "ONLINE"})
+	if allProxySQLServersDown(up) {
+		t.Error("one ONLINE: expected not down")
+	}
+	if allProxySQLServersDown(nil) {
+		t.Error("no servers: expected not down")
+	}
+}
+
+func TestAllBackendsDownBannerAndJSON(t *testing.T) {
+	resetGlobals(t)
+	srv := haproxyStatsServer(t, haproxyCSVHeader+
+		"mysql-primary,pxc-0,DOWN,10.0.0.1:3306,0,100,12,L4CON,5,,1,Connection refused\n"+
+		"mysql-primary,pxc-1,MAINT,10.0.0.2:3306,0,100,7,,60,,,\n")
+	cfg.HAProxyStatsURL = srv.URL + "/stats"
+	cfg.StatsTimeout = 2 * time.Second
+
+	out := captureStdout(t, func() { printHAProxyStats(context.Background()) })
+	if !strings.Contains(out, "ALL BACKENDS DOWN") {
+		t.Errorf("banner missing from output:\n%s", out)
+	}
+
+	r := buildJSONReport(context.Background(), nil)
+	if !r.AllBackendsDown {
+		t.Error("allBackendsDown = false, want true")
+	}
+	if len(r.HAProxyBackends) != 2 {
+		t.Errorf("got %d backends, want 2", len(r.HAProxyBackends))
+	}
+}
+
+func TestHAProxyL7CheckDetail(t *testing.T) {
+	resetGlobals(t)
+	srv := haproxyStatsServer(t, haproxyCSVHeader+
+		"mysql-primary,pxc-0,DOWN,10.0.0.1:3306,0,100,12,* L7STS,5,503,12,HTTP status check returned code 503\n"+
+		"mysql-primary,pxc-1,UP,10.0.0.2:3306,3,100,7,L7OK,60,200,2,\n")
+	cfg.HAProxyStatsURL = srv.URL + "/stats"
+	cfg.StatsTimeout = 2 * time.Second
+
+	backends, err := fetchHAProxyStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := backends[0]
+	if down.CheckCode != "503" || down.CheckDuration != "12" || down.LastCheck != "HTTP status check returned code 503" {
+		t.Errorf("check detail = %q/%q/%q", down.CheckCode, down.CheckDuration, down.LastCheck)
+	}
+
+	want := "L7STS layer 7 bad status (code 503, 12ms): HTTP status check returned code 503"
+	if got := checkFailureReason(down); got != want {
+		t.Errorf("reason = %q, want %q", got, want)
+	}
+
+	out := captureStdout(t, func() { printHAProxyStats(context.Background()) })
+	if !strings.Contains(out, "pxc-0 DOWN: "+want) {
+		t.Errorf("panel missing the failing check reason:\n%s", out)
+	}
+	if strings.Contains(out, "pxc-1 DOWN") {
+		t.Errorf("reason shown for an UP backend:\n%s", out)
+	}
+}
+
+func TestNoBannerWhileABackendIsUp(t *testing.T) {
+	resetGlobals(t)
+	srv := haproxyStatsServer(t, haproxyCSVHeader+
+		"mysql-primary,pxc-0,DOWN,10.0.0.1:3306,0,100,12,L4CON,5,,1,Connection refused\n"+
+		"mysql-primary,pxc-1,no check,10.0.0.2:3306,3,100,7,,60,,,\n")
+	cfg.HAProxyStatsURL = srv.URL + "/stats"
+	cfg.StatsTimeout = 2 * time.Second
+
+	out := captureStdout(t, func() { printHAProxyStats(context.Background()) })
+	if strings.Contains(out, "ALL BACKENDS DOWN") {
+		t.Errorf("unexpected banner:\n%s", out)
+	}
+	if buildJSONReport(context.Background(), nil).AllBackendsDown {
+		t.Error("allBackendsDown = true, want false")
+	}
+}
+
+func TestAcquireDelayAttributedToAcquire(t *testing.T) {
+	resetGlobals(t)
+	cfg.ConnectionTimeout = 5 * time.Second
+	cfg.BackendIDQuery = "SELECT @@hostname"
+
+	const delay = 50 * time.Millisecond
+	db, c := newFakeDB(t, proxyHandler("pxc-0", 1))
+	c.connectDelay = delay
+	p := newTestPool("proxy:3306", db)
+
+	executeRead(context.Background(), p)
+	executeWrite(context.Background(), p)
+
+	if stats.TotalReads != 1 || stats.TotalWrites != 1 {
+		t.Fatalf("reads/writes = %d/%d, want 1/1 (errors: %v)", stats.TotalReads, stats.TotalWrites, stats.ConnectionErrors)
+	}
+	if stats.MaxAcquireLatency < delay {
+		t.Errorf("max acquire = %s, want at least the %s connect delay", stats.MaxAcquireLatency, delay)
+	}
+	if stats.MaxReadLatency >= delay || stats.MaxWriteLatency >= delay {
+		t.Errorf("query latency read %s / write %s includes the connect delay", stats.MaxReadLatency, stats.MaxWriteLatency)
+	}
+}
+
+func TestMultipleProxyEndpoints(t *testing.T) {
+	resetGlobals(t)
+	cfg.ProxyHosts = []string{"haproxy-a:3306", "haproxy-b:3306"}
+	cfg.ProxyUser, cfg.Database = "root", "test"
+	cfg.PoolSize, cfg.MinIdle = 4, 1
+	cfg.ConnectionTimeout, cfg.ReadTimeout, cfg.WriteTimeout = time.Second, time.Second, time.Second
+
+	endpoints, err := proxyEndpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pools []*ProxyPool
+	for _, addr := range endpoints {
+		db, err := openProxyPool(addr)
+		if err != nil {
+			t.Fatalf("%s: %v", addr, err)
+		}
+		defer db.Close()
+		pools = append(pools, newTestPool(addr, db))
+	}
+	if len(pools) != 2 {
+		t.Fatalf("got %d pools, want 2", len(pools))
+	}
+
+	out := captureStdout(t, func() { printPoolStats(pools) })
+	if !strings.Contains(out, "[PROXY ENDPOINTS]") {
+		t.Errorf("per-endpoint panel missing:\n%s", out)
+	}
+	for _, addr := range cfg.ProxyHosts {
+		if !strings.Contains(out, addr) {
+			t.Errorf("endpoint %s not displayed:\n%s", addr, out)
+		}
+	}
+	if !strings.Contains(out, "0/8") {
+		t.Errorf("combined pool size 0/8 not displayed:\n%s", out)
+	}
+
+	cfg.ProxyHosts = []string{"haproxy-a"}
+	if _, err := proxyEndpoints(); err == nil {
+		t.Error("entry without a port: expected an error")
+	}
+}
+
+// pxcHandler answers fetchPXCNodeStatus' queries for one node, delaying the
+// --probe-nodes SELECT 1 by probeDelay
+func pxcHandler(name string, probeDelay time.Duration, wsrep map[string]string) fakeHandler {
+	return func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		switch {
+		case query == "SELECT 1":
+			time.Sleep(probeDelay)
+			return scalar(int64(1))
+		case query == "SELECT @@hostname":
+			return scalar(name)
+		case strings.HasPrefix(query, "SHOW GLOBAL STATUS"):
+			r := fakeResult{columns: []string{"Variable_name", "Value"}}
+			for k, v := range wsrep {
+				r.rows = append(r.rows, []driver.Value{k, v})
+			}
+			return r
+		case strings.Contains(query, "processlist"):
+			return scalar(int64(12))
+		case query == "SELECT @@max_connections":
+			return scalar(int64(200))
+		}
+		return fakeResult{err: fmt.Errorf("unexpected query %q", query)}
+	}
+}
+
+// setPXCNodeDB makes fetchPXCNodeStatus use db for addr
+func setPXCNodeDB(t *testing.T, addr string, db *sql.DB) {
+	t.Helper()
+	pxcNodeDBsMu.Lock()
+	pxcNodeDBs[addr] = db
+	pxcNodeDBsMu.Unlock()
+	t.Cleanup(func() {
+		pxcNodeDBsMu.Lock()
+		delete(pxcNodeDBs, addr)
+		pxcNodeDBsMu.Unlock()
+	})
+}
+
+func TestProbeNodesPerNodeLatency(t *testing.T) {
+	resetGlobals(t)
+	cfg.ProbeNodes = true
+	cfg.StatsTimeout = 2 * time.Second
+	cfg.PXCNodes = []string{"pxc-0:3306", "pxc-1:3306"}
+
+	const connectDelay = 80 * time.Millisecond
+	const slowProbe = 30 * time.Millisecond
+	synced := map[string]string{"wsrep_local_state_comment": "Synced", "wsrep_cluster_status": "Primary"}
+
+	fastDB, fast := newFakeDB(t, pxcHandler("pxc-0", 0, synced))
+	slowDB, slow := newFakeDB(t, pxcHandler("pxc-1", slowProbe, synced))
+	fast.connectDelay, slow.connectDelay = connectDelay, connectDelay
+	setPXCNodeDB(t, "pxc-0:3306", fastDB)
+	setPXCNodeDB(t, "pxc-1:3306", slowDB)
+
+	var statuses []PXCNodeStatus
+	for i := 0; i < 2; i++ {
+		statuses = refreshPXC(context.Background())
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("got %d statuses, want 2", len(statuses))
+	}
+	for _, s := range statuses {
+		if s.ProbeError != "" {
+			t.Fatalf("%s: probe error %s", s.Address, s.ProbeError)
+		}
+		if s.ProbeLatency >= connectDelay {
+			t.Errorf("%s: probe latency %s includes the connect delay", s.Address, s.ProbeLatency)
+		}
+	}
+	if statuses[0].ProbeLatency >= slowProbe {
+		t.Errorf("pxc-0 probe = %s, want under %s", statuses[0].ProbeLatency, slowProbe)
+	}
+	if statuses[1].ProbeLatency < slowProbe {
+		t.Errorf("pxc-1 probe = %s, want at least %s", statuses[1].ProbeLatency, slowProbe)
+	}
+	if fast.nextID != 1 || slow.nextID != 1 {
+		t.Errorf("connections opened: %d and %d, want one per node reused across refreshes", fast.nextID, slow.nextID)
+	}
+
+	out := captureStdout(t, func() { printPXCStatus(context.Background()) })
+	if !strings.Contains(out, "PROBE") {
+		t.Errorf("probe column missing:\n%s", out)
+	}
+	for _, name := range []string{"pxc-0", "pxc-1"} {
+		if !strings.Contains(out, name) {
+			t.Errorf("%s not displayed:\n%s", name, out)
+		}
+	}
+}
+
+func TestProxySQLQueryRules(t *testing.T) {
+	resetGlobals(t)
+	cfg.StatsTimeout = 2 * time.Second
+
+	db, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		if !strings.Contains(query, "FROM mysql_query_rules") {
+			return fakeResult{err: fmt.Errorf("unexpected query %q", query)}
+		}
+		return fakeResult{
+			columns: []string{"rule_id", "active", "match_pattern", "destination_hostgroup", "apply"},
+			rows: [][]driver.Value{
+				{int64(100), int64(1), "^SELECT .* FOR UPDATE", int64(10), int64(1)},
+				{int64(200), int64(1), "^SELECT", int64(20), int64(1)},
+				{int64(300), int64(0), "^SHOW", nil, int64(0)},
+			},
+		}
+	})
+
+	rules, err := fetchProxySQLQueryRules(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("got %d rules, want 3", len(rules))
+	}
+	for i, id := range []int{100, 200, 300} {
+		if rules[i].RuleID != id {
+			t.Errorf("rule %d id = %d, want %d", i, rules[i].RuleID, id)
+		}
+	}
+	if !rules[0].DestinationHostgroup.Valid || rules[0].DestinationHostgroup.Int64 != 10 {
+		t.Errorf("rule 100 destination = %v, want 10", rules[0].DestinationHostgroup)
+	}
+	if rules[2].DestinationHostgroup.Valid || rules[2].Active != 0 {
+		t.Errorf("rule 300 = %+v, want inactive with no destination", rules[2])
+	}
+
+	out := captureStdout(t, func() { printProxySQLQueryRules(context.Background(), db) })
+	first, second, third := strings.Index(out, "FOR UPDATE"), strings.Index(out, "200"), strings.Index(out, "^SHOW")
+	if first < 0 || second < 0 || third < 0 || !(first < second && second < third) {
+		t.Errorf("rules not rendered in rule order:\n%s", out)
+	}
+}
+
+func TestProxySQLGlobalStats(t *testing.T) {
+	db, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		return fakeResult{
+			columns: []string{"Variable_Name", "Variable_Value"},
+			rows: [][]driver.Value{
+				{"Questions", "123456"},
+				{"Slow_queries", "7"},
+				{"Active_Transactions", "3"},
+				{"Client_Connections_connected", "42"},
+				{"Client_Connections_aborted", "2"},
+				{"Server_Connections_connected", "18"},
+				{"Server_Connections_aborted", "1"},
+				{"ConnPool_memory_bytes", "1048576"},
+				{"Query_Cache_Memory_bytes", "0"},
+				{"ProxySQL_Uptime", "3600"},
+			},
+		}
+	})
+
+	got, err := fetchProxySQLGlobalStats(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ProxySQLGlobalStats{
+		Questions:                  123456,
+		SlowQueries:                7,
+		ActiveTransactions:         3,
+		ClientConnectionsConnected: 42,
+		ClientConnectionsAborted:   2,
+		ServerConnectionsConnected: 18,
+		ServerConnectionsAborted:   1,
+		ConnPoolMemoryBytes:        1048576,
+	}
+	if got != want {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestWriteSplitViolation(t *testing.T) {
+	resetGlobals(t)
+
+	recordWrite(time.Millisecond, "pxc-0", 101)
+	recordWrite(time.Millisecond, "pxc-0", 101)
+	recordRead(time.Millisecond, "pxc-1", 102)
+	// A failed identity lookup must not count as another writer
+	recordWrite(time.Millisecond, "unknown", unknownServerID)
+
+	if n := writeNodeCount(); n != 1 {
+		t.Fatalf("writeNodeCount = %d, want 1", n)
+	}
+	out := captureStdout(t, printRoutingStats)
+	if strings.Contains(out, "WRITE SPLIT VIOLATION") {
+		t.Errorf("unexpected violation with a single writer:\n%s", out)
+	}
+
+	// Same hostname, different server: still a second writer
+	recordWrite(time.Millisecond, "pxc-0", 103)
+	if n := writeNodeCount(); n != 2 {
+		t.Fatalf("writeNodeCount = %d, want 2", n)
+	}
+	out = captureStdout(t, printRoutingStats)
+	if !strings.Contains(out, "WRITE SPLIT VIOLATION: writes landed on 2 nodes") {
+		t.Errorf("violation not flagged:\n%s", out)
+	}
+	for _, id := range []string{"101", "102", "103"} {
+		if !strings.Contains(out, id) {
+			t.Errorf("server id %s not displayed:\n%s", id, out)
+		}
+	}
+}
+
+func TestBackendIDQueryUsedForReads(t *testing.T) {
+	resetGlobals(t)
+	cfg.ConnectionTimeout = 2 * time.Second
+	cfg.BackendIDQuery = "SELECT @@wsrep_node_name;"
+	db, c := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		if query == "SELECT (SELECT @@wsrep_node_name), @@server_id" {
+			return fakeResult{columns: []string{"node", "server_id"}, rows: [][]driver.Value{{"pxc-2", int64(3)}}}
+		}
+		return proxyHandler("wrong", 9)(ctx, connID, query, args)
+	})
+
+	if err := validateBackendIDQuery(context.Background(), db); err != nil {
+		t.Fatalf("validateBackendIDQuery: %v", err)
+	}
+	executeRead(context.Background(), newTestPool("proxy:3306", db))
+
+	if stats.LastBackendNode != "pxc-2" {
+		t.Errorf("last backend = %q, want pxc-2 (queries: %q)", stats.LastBackendNode, c.Queries())
+	}
+}
+
+func TestValidateBackendIDQueryFailsFast(t *testing.T) {
+	resetGlobals(t)
+	cfg.BackendIDQuery = "SELECT @@wsrep_nodename"
+	db, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		return fakeResult{err: fmt.Errorf("Unknown system variable 'wsrep_nodename'")}
+	})
+
+	err := validateBackendIDQuery(context.Background(), db)
+	if err == nil {
+		t.Fatal("expected an error for a failing query")
+	}
+	if !strings.Contains(err.Error(), "--backend-id-query") || !strings.Contains(err.Error(), "wsrep_nodename") {
+		t.Errorf("error %q does not name the flag and variable", err)
+	}
+}
+
+func TestBackendIdentityFailureIsUnknown(t *testing.T) {
+	resetGlobals(t)
+	cfg.BackendIDQuery = "SELECT @@hostname"
+	db, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		return fakeResult{err: fmt.Errorf("lookup failed")}
+	})
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	node, id := backendIdentity(context.Background(), conn)
+	if node != "unknown" || id != unknownServerID {
+		t.Errorf("got %q/%d, want unknown/%d", node, id, unknownServerID)
+	}
+}
+
+func TestReconnectBackoffGrowsAndCaps(t *testing.T) {
+	resetGlobals(t)
+	cfg.ReconnectBackoff = 100 * time.Millisecond
+	cfg.ReconnectMax = 800 * time.Millisecond
+
+	b := &ReconnectBackoff{}
+	for i, base := range []time.Duration{100, 200, 400, 800, 800, 800} {
+		base *= time.Millisecond
+		b.Fail()
+		failures, current := b.State()
+		if failures != i+1 {
+			t.Errorf("failure %d: count = %d", i+1, failures)
+		}
+		// Jitter keeps the delay within the upper half of the base delay
+		if current < base/2 || current > base {
+			t.Errorf("failure %d: backoff %s outside [%s, %s]", i+1, current, base/2, base)
+		}
+	}
+	if !b.Paused() {
+		t.Error("expected the workload to be paused while backing off")
+	}
+
+	b.Succeed()
+	if failures, current := b.State(); failures != 0 || current != 0 || b.Paused() {
+		t.Errorf("after success: %d failures, %s backoff, paused %v", failures, current, b.Paused())
+	}
+
+	cfg.ReconnectBackoff = 0
+	b.Fail()
+	if failures, _ := b.State(); failures != 0 {
+		t.Error("backoff disabled: failure was still recorded")
+	}
+}
+
+func TestStatusPageUnderQuiet(t *testing.T) {
+	resetGlobals(t)
+	cfg.Quiet = true
+	cfg.StatsTimeout = 2 * time.Second
+	srv := haproxyStatsServer(t, haproxyCSVHeader+
+		"mysql-primary,pxc-0,UP,10.0.0.1:3306,4,100,12,L7OK,5,200,1,\n")
+	cfg.HAProxyStatsURL = srv.URL + "/stats"
+	cfg.PXCNodes = []string{"pxc-0:3306"}
+	nodeDB, _ := newFakeDB(t, pxcHandler("pxc-0", 0, map[string]string{"wsrep_local_state_comment": "Synced"}))
+	setPXCNodeDB(t, "pxc-0:3306", nodeDB)
+
+	recordRead(20*time.Millisecond, "pxc-0", 101)
+	recordRead(60*time.Millisecond, "pxc-1", 102)
+	recordWrite(5*time.Millisecond, "pxc-0", 101)
+	recordWrite(9*time.Millisecond, "pxc-1", 102)
+
+	// With no dashboard running, the snapshot loop is what fills the page
+	refreshSnapshot(context.Background())
+
+	rec := httptest.NewRecorder()
+	handleStatusPage(rec, nil)
+	page := rec.Body.String()
+
+	for _, want := range []string{
+		`id="haproxy-backends"`,
+		"10.0.0.1:3306",
+		`id="pxc-nodes"`,
+		"Synced",
+		"Min/Max Read</td><td>20ms / 60ms",
+		"Min/Max Write</td><td>5ms / 9ms",
+		`id="routing"`,
+		"<td>pxc-1</td><td>102</td><td>1</td><td>50.0%</td>",
+		"WRITE SPLIT VIOLATION: writes landed on 2 nodes",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestStatusPageFlowControl(t *testing.T) {
+	resetGlobals(t)
+	t.Cleanup(flowControlHistory.Reset)
+	cfg.FCSustainedRefreshes = 3
+
+	start := time.Now()
+	// Cumulative paused_ns every 2s: fractions 0%, 5%
+	for i, pausedNs := range []string{"0", "0", "100000000"} {
+		node := []PXCNodeStatus{{Address: "pxc-0:3306", NodeName: "pxc-0", FlowControl: pausedNs}}
+		flowControlHistory.Track(node, start.Add(time.Duration(i)*2*time.Second))
+		snapshot.setPXC(node)
+	}
+
+	rec := httptest.NewRecorder()
+	handleStatusPage(rec, nil)
+	page := rec.Body.String()
+
+	if !strings.Contains(page, `<td class="warn">5.0% ↑</td>`) {
+		t.Errorf("flow control not shown as the per-refresh fraction and trend:\n%s", page)
+	}
+	if strings.Contains(page, "100000000") {
+		t.Error("page shows the raw cumulative paused_ns counter")
+	}
+}
+
+func TestMetricsServesSnapshot(t *testing.T) {
+	resetGlobals(t)
+	recordRead(20*time.Millisecond, "pxc-0", 101)
+	recordWrite(5*time.Millisecond, "pxc-0", 101)
+	snapshot.setHAProxy([]HAProxyBackend{{Name: "pxc-0", Status: "DOWN"}}, nil)
+	snapshot.setPXC([]PXCNodeStatus{{Address: "pxc-0:3306", LocalState: "Synced"}})
+	cfg.UseProxySQL = false
+
+	rec := httptest.NewRecorder()
+	handleMetrics(rec, nil)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var r JSONReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+		t.Fatalf("decoding /metrics: %v", err)
+	}
+	if r.TotalReads != 1 || r.TotalWrites != 1 {
+		t.Errorf("totals = %d reads, %d writes, want 1 and 1", r.TotalReads, r.TotalWrites)
+	}
+	if len(r.PXCNodes) != 1 || r.PXCNodes[0].LocalState != "Synced" {
+		t.Errorf("pxc nodes = %+v, want the snapshot's node", r.PXCNodes)
+	}
+	if !r.AllBackendsDown {
+		t.Error("allBackendsDown not set with every HAProxy backend DOWN")
+	}
+}
+
+func TestErrorCategory(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"1040", &mysql.MySQLError{Number: 1040, Message: "Too many connections"}, ErrCategoryTooManyConnections},
+		{"1205", &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, ErrCategoryLockWaitTimeout},
+		{"1213", &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, ErrCategoryDeadlock},
+		{"2006", &mysql.MySQLError{Number: 2006, Message: "MySQL server has gone away"}, ErrCategoryGoneAway},
+		{"2013", &mysql.MySQLError{Number: 2013, Message: "Lost connection to MySQL server"}, ErrCategoryLostConnection},
+		{"wrapped 1040", fmt.Errorf("read: %w", &mysql.MySQLError{Number: 1040}), ErrCategoryTooManyConnections},
+		{"invalid conn", mysql.ErrInvalidConn, ErrCategoryGoneAway},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}, ErrCategoryRefused},
+		{"net timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, ErrCategoryTimeout},
+		{"context deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), ErrCategoryTimeout},
+		{"EOF", io.ErrUnexpectedEOF, ErrCategoryLostConnection},
+		{"message only", errors.New("dial tcp 10.0.0.1:3306: connect: connection refused"), ErrCategoryRefused},
+		{"other", &mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}, ErrCategoryOther},
+	} {
+		if got := errorCategory(tc.err); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestErrorBreakdownPanel(t *testing.T) {
+	resetGlobals(t)
+
+	recordError("read", &mysql.MySQLError{Number: 1040, Message: "Too many connections"}, "pxc-0")
+	recordError("read", &mysql.MySQLError{Number: 1040, Message: "Too many connections"}, "pxc-0")
+	recordError("write", &mysql.MySQLError{Number: 2013, Message: "Lost connection"}, "pxc-1")
+
+	if stats.FailedReads != 2 || stats.FailedWrites != 1 {
+		t.Errorf("failed reads/writes = %d/%d, want 2/1", stats.FailedReads, stats.FailedWrites)
+	}
+	out := captureStdout(t, printErrorBreakdown)
+	if !strings.Contains(out, "[ERROR BREAKDOWN]") {
+		t.Fatalf("panel missing:\n%s", out)
+	}
+	exhaustion, lost := strings.Index(out, ErrCategoryTooManyConnections), strings.Index(out, ErrCategoryLostConnection)
+	if exhaustion < 0 || lost < 0 || exhaustion > lost {
+		t.Errorf("want both categories, most frequent first:\n%s", out)
+	}
+	if !strings.Contains(out, "66.7%") {
+		t.Errorf("share missing:\n%s", out)
+	}
+}
+
+func TestBuildProxyDSN(t *testing.T) {
+	resetGlobals(t)
+	cfg.ProxyUser, cfg.ProxyPassword, cfg.Database = "monitor", "secret", "connpool"
+	cfg.ConnectionTimeout = 3 * time.Second
+	cfg.ReadTimeout = 7 * time.Second
+	cfg.WriteTimeout = 9 * time.Second
+	cfg.DSNParams = "interpolateParams=true&collation=utf8mb4_general_ci"
+
+	dsn, err := buildProxyDSN("haproxy:3306")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("driver rejects %q: %v", dsn, err)
+	}
+	if parsed.Addr != "haproxy:3306" || parsed.DBName != "connpool" || parsed.User != "monitor" {
+		t.Errorf("addr/db/user = %s/%s/%s", parsed.Addr, parsed.DBName, parsed.User)
+	}
+	if parsed.Timeout != 3*time.Second || parsed.ReadTimeout != 7*time.Second || parsed.WriteTimeout != 9*time.Second {
+		t.Errorf("timeouts = %s/%s/%s, want 3s/7s/9s", parsed.Timeout, parsed.ReadTimeout, parsed.WriteTimeout)
+	}
+	if !parsed.InterpolateParams || parsed.Collation != "utf8mb4_general_ci" {
+		t.Errorf("passthrough params not applied: %q", dsn)
+	}
+
+	cfg.DSNParams = "readTimeout=1s"
+	if _, err := buildProxyDSN("haproxy:3306"); err == nil || !strings.Contains(err.Error(), "readTimeout") {
+		t.Errorf("conflicting --dsn-params: err = %v, want rejection naming readTimeout", err)
+	}
+
+	cfg.DSNParams = ""
+	cfg.WriteTimeout = 0
+	if _, err := buildProxyDSN("haproxy:3306"); err == nil || !strings.Contains(err.Error(), "--write-timeout") {
+		t.Errorf("zero --write-timeout: err = %v, want rejection", err)
+	}
+}
+
+func TestStressExhaustWaitCount(t *testing.T) {
+	resetGlobals(t)
+	stress = StressState{}
+	t.Cleanup(func() { stress = StressState{} })
+	cfg.PoolSize = 2
+
+	db, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		return scalar(int64(1))
+	})
+	db.SetMaxOpenConns(cfg.PoolSize)
+	pools := []*ProxyPool{newTestPool("haproxy:3306", db)}
+
+	// Baseline before any holder, as the first refresh would see it
+	captureStdout(t, func() { printStressStats(pools) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < cfg.PoolSize+1; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				holdConnection(ctx, db)
+			}()
+		}
+		wg.Wait()
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for db.Stats().WaitCount == 0 || atomic.LoadInt64(&stress.held) < int64(cfg.PoolSize) {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool never exhausted: held %d, stats %+v", atomic.LoadInt64(&stress.held), db.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	out := captureStdout(t, func() { printStressStats(pools) })
+	if !strings.Contains(out, "Pool exhausted") {
+		t.Errorf("exhaustion not reported:\n%s", out)
+	}
+	if !regexp.MustCompile(`Wait Count\s*\|\s*[1-9]`).MatchString(out) {
+		t.Errorf("nonzero wait count not shown:\n%s", out)
+	}
+}
+
+func TestFlowControlTrendAndSustained(t *testing.T) {
+	resetGlobals(t)
+	cfg.FCSustainedRefreshes = 3
+	savedNoColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = savedNoColor })
+
+	start := time.Now()
+	h := &FlowControlHistory{}
+	// Cumulative paused_ns every 2s: fractions 0%, 5%, 20%, 50%
+	for i, pausedNs := range []int64{0, 0, 100e6, 500e6, 1500e6} {
+		h.Add(pausedNs, start.Add(time.Duration(i)*2*time.Second))
+	}
+
+	if len(h.Fractions) != 4 {
+		t.Fatalf("got %d fractions, want 4 (first sample is the baseline)", len(h.Fractions))
+	}
+	if got := h.Trend(); got != "↑" {
+		t.Errorf("trend = %s, want ↑", got)
+	}
+	if !h.Sustained(3) || h.Sustained(4) {
+		t.Errorf("nonzero run = %d, want 3", h.NonZeroRun)
+	}
+	text := formatFlowControl(h)
+	if !strings.Contains(text, "50.0% ↑") || !strings.Contains(text, "\x1b[31m") {
+		t.Errorf("want 50.0%% ↑ in red, got %q", text)
+	}
+
+	// A refresh without pauses ends the run and turns the trend down
+	h.Add(1500e6, start.Add(10*time.Second))
+	if got := h.Trend(); got != "↓" {
+		t.Errorf("trend after a quiet refresh = %s, want ↓", got)
+	}
+	if h.Sustained(1) {
+		t.Error("still sustained after a refresh without pauses")
+	}
+}
+
+func TestResetStatsKeepsPool(t *testing.T) {
+	resetGlobals(t)
+	t.Cleanup(poolHistory.Reset)
+	cfg.PoolSize = 1
+
+	db, conns := newFakeDB(t, proxyHandler("pxc-0", 101))
+	db.SetMaxOpenConns(cfg.PoolSize)
+	p := newTestPool("haproxy:3306", db)
+	pools := []*ProxyPool{p}
+
+	// One caller waits for the single connection, so the pool has a wait
+	ctx := context.Background()
+	held, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waited := make(chan error)
+	go func() {
+		_, err := db.ExecContext(ctx, "INSERT INTO connpool_test (data) VALUES ('x')")
+		waited <- err
+	}()
+	for db.Stats().WaitCount == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	held.Close()
+	if err := <-waited; err != nil {
+		t.Fatal(err)
+	}
+
+	recordRead(10*time.Millisecond, "pxc-0", 101)
+	recordWrite(5*time.Millisecond, "pxc-0", 101)
+	recordError("read", errors.New("connection refused"), "pxc-0")
+	atomic.StoreInt64(&readOps, 7)
+	poolHistory.Sample(pools)
+
+	resetStats(pools)
+
+	if stats.TotalReads != 0 || stats.TotalWrites != 0 || stats.FailedReads != 0 || stats.MaxReadLatency != 0 || stats.MinWriteLatency != 0 {
+		t.Errorf("counters not zeroed: reads %d, writes %d, failed reads %d", stats.TotalReads, stats.TotalWrites, stats.FailedReads)
+	}
+	if len(stats.ConnectionErrors) != 0 || stats.ErrorCategories != nil || stats.ErrorGroups != nil || stats.Routing != nil {
+		t.Error("error buffer, categories, digest or routing not cleared")
+	}
+	if atomic.LoadInt64(&readOps) != 0 || len(poolHistory.Samples()) != 0 {
+		t.Error("throughput counters or pool history not cleared")
+	}
+	if stats.ResetAt.IsZero() {
+		t.Error("reset time not recorded")
+	}
+	if got := p.Stats().WaitCount; got != 0 {
+		t.Errorf("wait count after reset = %d, want 0 from the new baseline", got)
+	}
+
+	// The pool itself is untouched: same connection, still usable
+	if open := db.Stats().OpenConnections; open != 1 {
+		t.Errorf("open connections = %d, want 1", open)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO connpool_test (data) VALUES ('y')"); err != nil {
+		t.Fatalf("pool unusable after reset: %v", err)
+	}
+	if conns.nextID != 1 {
+		t.Errorf("reset opened %d connections, want the original one reused", conns.nextID)
+	}
+
+	out := captureStdout(t, printHeader)
+	if !strings.Contains(out, "stats reset at") {
+		t.Errorf("reset marker missing:\n%s", out)
+	}
+}
+
+func TestProxySQLAdminDSNUsesTLS(t *testing.T) {
+	resetGlobals(t)
+	t.Cleanup(func() { tlsDSNParam = "" })
+	cfg.ProxySQLAdminUser, cfg.ProxySQLAdminPassword = "radmin", "radmin"
+	cfg.ProxySQLAdminHost, cfg.ProxySQLAdminPort = "proxysql", 6032
+
+	// Any certificate will do as a CA bundle
+	tlsSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	tlsSrv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsSrv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		mode, ca string
+		want     string
+	}{
+		{"disabled", "", ""},
+		{"preferred", "", "preferred"},
+		{"skip-verify", "", "skip-verify"},
+		{"verify", "", "true"},
+		{"verify", caFile, tlsConfigName},
+	} {
+		cfg.TLSMode, cfg.TLSCA = tc.mode, tc.ca
+		if err := setupTLS(); err != nil {
+			t.Fatalf("%s: %v", tc.mode, err)
+		}
+		dsn := proxySQLAdminDSN()
+		parsed, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			t.Fatalf("%s: driver rejects %q: %v", tc.mode, dsn, err)
+		}
+		if parsed.TLSConfig != tc.want {
+			t.Errorf("--tls-mode=%s --tls-ca=%q: admin DSN tls = %q, want %q", tc.mode, tc.ca, parsed.TLSConfig, tc.want)
+		}
+		if parsed.Addr != "proxysql:6032" {
+			t.Errorf("admin addr = %s", parsed.Addr)
+		}
+	}
+}
+
+func TestErrorSummaryGroupsNormalizedMessages(t *testing.T) {
+	resetGlobals(t)
+	start := time.Date(2026, 3, 4, 10, 2, 11, 0, time.UTC)
+
+	for i, msg := range []string{
+		"dial tcp 10.0.0.1:3306: connect: connection refused",
+		"Error 1927: Connection was killed (thread 812)",
+		"dial tcp 10.0.0.2:3306: connect: connection refused",
+		"dial tcp [fd00::7]:3306: connect: connection refused",
+		"Error 1927: Connection was killed (thread 9001)",
+		"invalid connection",
+		"dial tcp 10.0.0.3:3306: connect: connection refused",
+	} {
+		stats.mu.Lock()
+		groupError(msg, start.Add(time.Duration(i)*time.Second))
+		stats.mu.Unlock()
+	}
+
+	groups := errorSummary()
+	want := []struct {
+		message     string
+		count       int64
+		first, last int
+	}{
+		{"dial tcp <addr>: connect: connection refused", 4, 0, 6},
+		{"Error 1927: Connection was killed (thread <id>)", 2, 1, 4},
+		{"invalid connection", 1, 5, 5},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d: %+v", len(groups), len(want), groups)
+	}
+	for i, w := range want {
+		g := groups[i]
+		if g.Message != w.message || g.Count != w.count {
+			t.Errorf("group %d = %q x %d, want %q x %d", i, g.Message, g.Count, w.message, w.count)
+		}
+		if !g.FirstSeen.Equal(start.Add(time.Duration(w.first)*time.Second)) || !g.LastSeen.Equal(start.Add(time.Duration(w.last)*time.Second)) {
+			t.Errorf("group %d seen %s to %s", i, g.FirstSeen.Format("15:04:05"), g.LastSeen.Format("15:04:05"))
+		}
+	}
+
+	out := captureStdout(t, printErrorSummary)
+	if !strings.Contains(out, "10:02:11") || !strings.Contains(out, "10:02:17") {
+		t.Errorf("first/last seen missing:\n%s", out)
+	}
+}
+
+func TestPXCNodeAddrs(t *testing.T) {
+	for _, tc := range []struct {
+		node string
+		want string
+	}{
+		{"10.0.0.1", "10.0.0.1:3306"},
+		{"10.0.0.1:3307", "10.0.0.1:3307"},
+		{" pxc-0.pxc.svc ", "pxc-0.pxc.svc:3306"},
+		{"pxc-1.pxc.svc:3310", "pxc-1.pxc.svc:3310"},
+		{"[::1]:3306", "[::1]:3306"},
+		{"[fd00::1]", "[fd00::1]:3306"},
+	} {
+		addrs, err := pxcNodeAddrs([]string{tc.node}, 3306)
+		if err != nil {
+			t.Errorf("%q: %v", tc.node, err)
+			continue
+		}
+		if addrs[0] != tc.want {
+			t.Errorf("%q: got %s, want %s", tc.node, addrs[0], tc.want)
+		}
+		// The driver must read back the same address from the node DSN
+		parsed, err := mysql.ParseDSN(fmt.Sprintf("monitor:secret@tcp(%s)/", addrs[0]))
+		if err != nil || parsed.Addr != tc.want {
+			t.Errorf("%q: DSN addr %v, err %v", tc.node, parsed, err)
+		}
+	}
+
+	for _, node := range []string{
+		"fd00::1",
+		"fd00::1:3306",
+		"pxc-0:",
+		":3306",
+		"pxc-0:99999",
+		"pxc-0:mysql",
+		"[fd00::1",
+	} {
+		if addrs, err := pxcNodeAddrs([]string{"pxc-ok", node}, 3306); err == nil {
+			t.Errorf("%q: accepted as %v", node, addrs)
+		} else if !strings.Contains(err.Error(), fmt.Sprintf("%q", node)) {
+			t.Errorf("%q: error does not name the entry: %v", node, err)
+		}
+	}
+
+	if _, err := pxcNodeAddrs(nil, 0); err == nil {
+		t.Error("--pxc-port 0 accepted")
+	}
+}
+
+func TestBothRequiresExplicitEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		ok   bool
+	}{
+		{[]string{}, true},
+		{[]string{"--proxysql"}, true},
+		{[]string{"--both", "--haproxy-stats-url", "http://haproxy:8404/stats", "--proxysql-admin-host", "proxysql"}, true},
+		// The localhost defaults don't count as configured
+		{[]string{"--both"}, false},
+		{[]string{"--both", "--haproxy-stats-url", "http://haproxy:8404/stats"}, false},
+		{[]string{"--both", "--proxysql-admin-host", "proxysql"}, false},
+	} {
+		resetGlobals(t)
+		cmd := newRootCmd()
+		if err := cmd.ParseFlags(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		if err := checkBothEndpoints(cmd); (err == nil) != tc.ok {
+			t.Errorf("%v: err = %v, want ok %v", tc.args, err, tc.ok)
+		}
+	}
+}
+
+func TestBothRendersHAProxyAndProxySQLPanels(t *testing.T) {
+	resetGlobals(t)
+	srv := haproxyStatsServer(t, haproxyCSVHeader+
+		"mysql-primary,pxc-0,UP,10.0.0.1:3306,4,100,12,L7OK,5,200,1,\n")
+	cfg.Both = true
+	cfg.HAProxyStatsURL = srv.URL + "/stats"
+	// Nothing listens on port 1, so the ProxySQL panel reports the failure
+	cfg.ProxySQLAdminHost, cfg.ProxySQLAdminPort = "127.0.0.1", 1
+	cfg.StatsTimeout = 2 * time.Second
+
+	out := captureStdout(t, func() { renderDashboard(context.Background(), nil) })
+	for _, want := range []string{"[HAPROXY BACKEND STATUS]", "10.0.0.1:3306", "[PROXYSQL STATUS]", "Error fetching server status"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q:\n%s", want, out)
+		}
+	}
+	if i, j := strings.Index(out, "[HAPROXY BACKEND STATUS]"), strings.Index(out, "[PROXYSQL STATUS]"); i > j {
+		t.Error("ProxySQL panel rendered before HAProxy")
+	}
+}
+
+func TestStaleReadCountedOutsideReadLatency(t *testing.T) {
+	resetGlobals(t)
+	cfg.ConsistencyCheck = true
+	cfg.ConsistencyGrace = 0
+	cfg.ConnectionTimeout = 2 * time.Second
+
+	// A lagging backend: the written row never shows up, and the visibility
+	// check itself is slow
+	const checkDelay = 60 * time.Millisecond
+	proxy := proxyHandler("pxc-1", 102)
+	db, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		if strings.HasPrefix(query, "SELECT COUNT(*)") {
+			time.Sleep(checkDelay)
+			return scalar(int64(0))
+		}
+		return proxy(ctx, connID, query, args)
+	})
+	p := newTestPool("proxysql:6033", db)
+	p.Consistency.Record(42)
+
+	executeRead(context.Background(), p)
+
+	if stats.ConsistencyChecks != 1 || stats.StaleReads != 1 {
+		t.Fatalf("checks/stale = %d/%d, want 1/1", stats.ConsistencyChecks, stats.StaleReads)
+	}
+	if stats.LastStaleReadNode != "pxc-1" {
+		t.Errorf("stale read node = %q, want pxc-1", stats.LastStaleReadNode)
+	}
+	if stats.TotalReads != 1 || stats.MaxReadLatency >= checkDelay {
+		t.Errorf("read latency %s includes the %s consistency check", stats.MaxReadLatency, checkDelay)
+	}
+
+	out := captureStdout(t, func() { printFooter([]*ProxyPool{p}) })
+	if !strings.Contains(out, "STALE READS: 1 of 1") {
+		t.Errorf("stale reads not in footer:\n%s", out)
+	}
+}
+
+func TestSparklineFollowsSeries(t *testing.T) {
+	for _, tc := range []struct {
+		values []float64
+		want   string
+	}{
+		{[]float64{0, 1, 2, 3, 4, 5, 6, 7}, "▁▂▃▄▅▆▇█"},
+		{[]float64{70, 60, 50, 40, 30, 20, 10, 0}, "█▇▆▅▄▃▂▁"},
+		{[]float64{0, 0, 0}, "▁▁▁"},
+		{[]float64{2, 2, 8, 2}, "▂▂█▂"},
+	} {
+		if got := sparkline(tc.values); got != tc.want {
+			t.Errorf("sparkline(%v) = %s, want %s", tc.values, got, tc.want)
+		}
+	}
+}
+
+func TestPoolHistoryRingAndTrend(t *testing.T) {
+	resetGlobals(t)
+
+	var h PoolHistory
+	for i := 0; i < poolHistorySize+5; i++ {
+		h.add(PoolSample{InUse: float64(i)})
+	}
+	samples := h.Samples()
+	if len(samples) != poolHistorySize {
+		t.Fatalf("got %d samples, want the last %d", len(samples), poolHistorySize)
+	}
+	if samples[0].InUse != 5 || samples[len(samples)-1].InUse != poolHistorySize+4 {
+		t.Errorf("ring holds %v..%v, want oldest first from 5", samples[0].InUse, samples[len(samples)-1].InUse)
+	}
+
+	// A connection spike during a failover, then recovery
+	poolHistory.Reset()
+	t.Cleanup(poolHistory.Reset)
+	poolHistory.mu.Lock()
+	for _, inUse := range []float64{1, 1, 4, 8, 8, 2} {
+		poolHistory.add(PoolSample{Open: 10, InUse: inUse})
+	}
+	poolHistory.mu.Unlock()
+
+	out := captureStdout(t, printPoolTrends)
+	if !strings.Contains(out, "Trend (last 6 refreshes)") {
+		t.Fatalf("trend header missing:\n%s", out)
+	}
+	if !strings.Contains(out, "In Use   ▁▁▄██▂ 2") {
+		t.Errorf("in-use sparkline doesn't show the spike:\n%s", out)
+	}
+	if !strings.Contains(out, "Open     ██████ 10") {
+		t.Errorf("flat open line wrong:\n%s", out)
+	}
+}
+
+// useLogFile points --log-file at a temp file for the rest of the test
+func useLogFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "connpool.log")
+	l, err := openErrorLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.LogFile = path
+	errorLog = l
+	t.Cleanup(func() {
+		errorLog.Close()
+		errorLog = nil
+	})
+	return path
+}
+
+// readLogRecords returns every JSON line written to the log file so far
+func readLogRecords(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var r map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestRecordErrorWritesLogOutsideStatsLock(t *testing.T) {
+	resetGlobals(t)
+	path := useLogFile(t)
+
+	// Stall the log as a slow disk would
+	errorLog.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		recordError("read", errors.New("connection refused"), "pxc-0")
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if stats.mu.TryRLock() {
+			failed := stats.FailedReads
+			stats.mu.RUnlock()
+			if failed == 1 {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			errorLog.mu.Unlock()
+			t.Fatal("stats.mu still held while the error log write is blocked")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	errorLog.mu.Unlock()
+	<-done
+
+	if records := readLogRecords(t, path); len(records) != 1 {
+		t.Errorf("got %d log records, want 1", len(records))
+	}
+}
+
+func TestLogFileKeepsFullErrors(t *testing.T) {
+	resetGlobals(t)
+	path := useLogFile(t)
+
+	long := "Error 2013 (HY000): Lost connection to MySQL server at 'reading initial communication packet', system error: 104"
+	recordError("read", &mysql.MySQLError{Number: 2013, Message: long}, "pxc-2")
+	recordError("write_conn", errors.New("dial tcp 10.0.0.9:3306: connect: connection refused"), "")
+
+	records := readLogRecords(t, path)
+	if len(records) != 2 {
+		t.Fatalf("got %d log lines, want 2", len(records))
+	}
+	first := records[0]
+	if first["event"] != "error" || first["operation"] != "read" || first["node"] != "pxc-2" || first["category"] != ErrCategoryLostConnection {
+		t.Errorf("first record = %v", first)
+	}
+	if msg, _ := first["error"].(string); !strings.Contains(msg, long) {
+		t.Errorf("message truncated: %q", msg)
+	}
+	if _, ok := records[1]["node"]; ok {
+		t.Errorf("empty node written: %v", records[1])
+	}
+	if records[1]["category"] != ErrCategoryRefused {
+		t.Errorf("second record = %v", records[1])
+	}
+}
+
+func TestJoinerCatchUpProgress(t *testing.T) {
+	resetGlobals(t)
+	cfg.StatsTimeout = 2 * time.Second
+	cfg.PXCNodes = []string{"pxc-0:3306", "pxc-2:3306"}
+	t.Cleanup(func() { joinerProgress = make(map[string]*JoinerProgress) })
+
+	synced := map[string]string{
+		"wsrep_local_state_comment": "Synced",
+		"wsrep_last_committed":      "1000",
+	}
+	syncedDB, _ := newFakeDB(t, pxcHandler("pxc-0", 0, synced))
+	setPXCNodeDB(t, "pxc-0:3306", syncedDB)
+
+	var joinerCommitted int64 = 400
+	joinerDB, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		wsrep := map[string]string{
+			"wsrep_local_state_comment": "Joiner",
+			"wsrep_last_committed":      strconv.FormatInt(atomic.LoadInt64(&joinerCommitted), 10),
+			"wsrep_local_recv_queue":    "37",
+			"wsrep_cert_deps_distance":  "12.5",
+		}
+		return pxcHandler("pxc-2", 0, wsrep)(ctx, connID, query, args)
+	})
+	setPXCNodeDB(t, "pxc-2:3306", joinerDB)
+
+	t0 := time.Now()
+	catchUp := func(at time.Time) CatchUp {
+		t.Helper()
+		out := estimateCatchUp(refreshPXC(context.Background()), at)
+		if len(out) != 1 {
+			t.Fatalf("got %d catch-up entries, want only the joiner: %+v", len(out), out)
+		}
+		return out[0]
+	}
+
+	c := catchUp(t0)
+	if c.Node != "pxc-2" || c.State != "Joiner" || c.Lag != 600 || c.Progress {
+		t.Errorf("first refresh = %+v, want pxc-2 Joiner 600 behind with no rate yet", c)
+	}
+	if c.RecvQueue != 37 || c.CertDepsDistance != 12.5 {
+		t.Errorf("queue fields not surfaced: recv %d, cert deps %.1f", c.RecvQueue, c.CertDepsDistance)
+	}
+
+	atomic.StoreInt64(&joinerCommitted, 700)
+	c = catchUp(t0.Add(10 * time.Second))
+	if !c.Progress || c.Lag != 300 || c.Rate != 30 || c.ETA != 10*time.Second {
+		t.Errorf("second refresh = %+v, want 300 behind closing at 30/s, ETA 10s", c)
+	}
+
+	c = catchUp(t0.Add(20 * time.Second))
+	if !c.Stalled {
+		t.Errorf("third refresh without progress = %+v, want stalled", c)
+	}
+
+	joinerProgress = make(map[string]*JoinerProgress)
+	out := captureStdout(t, func() { printCatchUp(refreshPXC(context.Background())) })
+	for _, want := range []string{"State transfer:", "pxc-2", "Joiner", "300 seqnos behind", "recv q 37, cert deps 12.5"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestProxySQLShunCount(t *testing.T) {
+	resetGlobals(t)
+	t.Cleanup(proxySQLStatusHistory.Reset)
+
+	server := func(status string) []ProxySQLConnPool {
+		return []ProxySQLConnPool{{HostgroupID: 10, SrvHost: "pxc-1", SrvPort: 3306, Status: status}}
+	}
+	start := time.Now()
+	for i, status := range []string{"ONLINE", "SHUNNED", "ONLINE"} {
+		proxySQLStatusHistory.Track(server(status), start.Add(time.Duration(i)*time.Second))
+	}
+
+	h := proxySQLStatusHistory.Get(server("ONLINE")[0])
+	if h.ShunCount != 1 || h.Status != "ONLINE" {
+		t.Errorf("history = %+v, want ONLINE with 1 shun", h)
+	}
+	if !h.LastChange.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("last change = %s, want the SHUNNED->ONLINE transition", h.LastChange)
+	}
+
+	proxySQLStatusHistory.Track(server("SHUNNED"), start.Add(3*time.Second))
+	if n := proxySQLStatusHistory.Get(server("SHUNNED")[0]).ShunCount; n != 2 {
+		t.Errorf("shun count after second shun = %d, want 2", n)
+	}
+
+	resetStats(nil)
+	if h := proxySQLStatusHistory.Get(server("SHUNNED")[0]); h.ShunCount != 0 || !h.LastChange.IsZero() {
+		t.Errorf("history after reset = %+v, want zero", h)
+	}
+}
+
+func TestProxySQLStatusResetConcurrentWithTrack(t *testing.T) {
+	resetGlobals(t)
+	t.Cleanup(proxySQLStatusHistory.Reset)
+
+	pool := []ProxySQLConnPool{{HostgroupID: 10, SrvHost: "pxc-1", SrvPort: 3306, Status: "ONLINE"}}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			proxySQLStatusHistory.Track(pool, time.Now())
+			proxySQLStatusHistory.Get(pool[0])
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			resetStats(nil)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestVerboseTracesToLogFile(t *testing.T) {
+	for _, verbose := range []bool{true, false} {
+		t.Run(fmt.Sprintf("verbose=%v", verbose), func(t *testing.T) {
+			resetGlobals(t)
+			cfg.ConnectionTimeout = 2 * time.Second
+			cfg.BackendIDQuery = "SELECT @@hostname"
+			cfg.Verbose = verbose
+			path := useLogFile(t)
+
+			db, _ := newFakeDB(t, proxyHandler("pxc-1", 2))
+			p := newTestPool("haproxy:3306", db)
+			executeRead(context.Background(), p)
+			executeWrite(context.Background(), p)
+			errorLog.Close()
+
+			records := readLogRecords(t, path)
+			if !verbose {
+				if len(records) != 0 {
+					t.Errorf("got %d log lines without --verbose, want none: %v", len(records), records)
+				}
+				return
+			}
+			if len(records) != 2 {
+				t.Fatalf("got %d trace lines, want 2: %v", len(records), records)
+			}
+			for i, op := range []string{"read", "write"} {
+				r := records[i]
+				if r["event"] != "query" || r["operation"] != op || r["node"] != "pxc-1" || r["endpoint"] != "haproxy:3306" {
+					t.Errorf("trace %d = %v, want a %s query on pxc-1", i, r, op)
+				}
+				if _, ok := r["conn_id"]; !ok {
+					t.Errorf("trace %d has no conn_id: %v", i, r)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionReportsInjectedBuildInfo(t *testing.T) {
+	prevCommit, prevTime := gitCommit, buildTime
+	t.Cleanup(func() { gitCommit, buildTime = prevCommit, prevTime })
+	gitCommit, buildTime = "abc1234", "2026-01-15T10:00:00Z"
+
+	rec := httptest.NewRecorder()
+	handleVersion(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	var info BuildInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	want := BuildInfo{Commit: "abc1234", BuildTime: "2026-01-15T10:00:00Z", GoVersion: runtime.Version()}
+	if info != want {
+		t.Errorf("/version = %+v, want %+v", info, want)
+	}
+
+	out := captureStdout(t, func() {
+		cmd := newRootCmd()
+		cmd.SetArgs([]string{"--version"})
+		if err := cmd.Execute(); err != nil {
+			t.Error(err)
+		}
+	})
+	if !strings.Contains(out, "commit abc1234, built 2026-01-15T10:00:00Z") {
+		t.Errorf("--version printed %q", out)
+	}
+}
+
+func TestPXCPortAppliedToBareHosts(t *testing.T) {
+	if def := newRootCmd().Flags().Lookup("pxc-port").DefValue; def != "3306" {
+		t.Errorf("--pxc-port default = %s, want 3306", def)
+	}
+
+	addrs, err := pxcNodeAddrs([]string{"pxc-0", "pxc-1:3306", "10.0.0.3", "[fd00::4]", "[fd00::5]:3310"}, 33060)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"pxc-0:33060", "pxc-1:3306", "10.0.0.3:33060", "[fd00::4]:33060", "[fd00::5]:3310"}
+	if !reflect.DeepEqual(addrs, want) {
+		t.Errorf("got %v, want %v", addrs, want)
+	}
+}
+
+func TestProxySQLWeightDriftFlagged(t *testing.T) {
+	columns := []string{"hostgroup_id", "hostname", "port", "status", "weight", "compression", "max_connections", "max_latency_ms", "comment"}
+	server := func(hg int64, host string, weight int64) []driver.Value {
+		return []driver.Value{hg, host, int64(3306), "ONLINE", weight, int64(0), int64(1000), int64(0), ""}
+	}
+	db, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		switch {
+		case strings.Contains(query, "FROM runtime_mysql_servers"):
+			return fakeResult{columns: columns, rows: [][]driver.Value{
+				server(10, "pxc-0", 1000), server(20, "pxc-1", 1000), server(20, "pxc-2", 1000),
+			}}
+		case strings.Contains(query, "FROM mysql_servers"):
+			return fakeResult{columns: columns, rows: [][]driver.Value{
+				server(10, "pxc-0", 1000), server(20, "pxc-1", 500),
+			}}
+		}
+		return fakeResult{err: fmt.Errorf("unexpected query %q", query)}
+	})
+
+	servers, runtimeOnly, err := fetchProxySQLServers(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("got %d config servers, want 2", len(servers))
+	}
+	if servers[0].Drift != "" {
+		t.Errorf("pxc-0 drift = %q, want none", servers[0].Drift)
+	}
+	if servers[1].Drift != "runtime weight 1000" {
+		t.Errorf("pxc-1 drift = %q, want runtime weight 1000", servers[1].Drift)
+	}
+	if got := formatServerDrift(servers[1].Drift); !strings.Contains(got, "config not loaded (runtime weight 1000)") {
+		t.Errorf("Runtime column = %q", got)
+	}
+	if len(runtimeOnly) != 1 || runtimeOnly[0].Hostname != "pxc-2" {
+		t.Errorf("runtime-only = %+v, want pxc-2", runtimeOnly)
+	}
+}
+
+func TestPoolEfficiencyFromSuccessiveStats(t *testing.T) {
+	var tracker PoolEfficiencyTracker
+	start := time.Now()
+	prev := sql.DBStats{OpenConnections: 10, MaxIdleClosed: 1, MaxLifetimeClosed: 5, WaitCount: 2}
+	cur := sql.DBStats{OpenConnections: 10, MaxIdleClosed: 1, MaxLifetimeClosed: 35, WaitCount: 12}
+
+	if _, ok := tracker.Sample(prev, 100, start); ok {
+		t.Fatal("first sample reported efficiency, want none until a delta exists")
+	}
+	e, ok := tracker.Sample(cur, 300, start.Add(30*time.Second))
+	if !ok {
+		t.Fatal("second sample reported no efficiency")
+	}
+
+	want := PoolEfficiency{Acquisitions: 200, Opened: 30, Closed: 30, Lifetime: 30, ChurnPerMin: 60, ReuseRatio: 85, WaitRatio: 5}
+	if e != want {
+		t.Errorf("got %+v, want %+v", e, want)
+	}
+	if !unhealthyChurn(e, 20) {
+		t.Error("60 closes/min on a 20 connection pool not flagged as unhealthy churn")
+	}
+	if unhealthyChurn(e, 100) {
+		t.Error("60 closes/min on a 100 connection pool flagged as unhealthy churn")
+	}
+
+	// A SIGUSR1 reset makes the ops counter go backwards
+	if _, ok := tracker.Sample(cur, 0, start.Add(time.Minute)); ok {
+		t.Error("sample after counters went backwards reported efficiency")
+	}
+}
+
+func TestPoolEfficiencyMaxLifetimeHint(t *testing.T) {
+	resetGlobals(t)
+	cfg.MaxLifetime = 10 * time.Second
+	poolEfficiency.Reset()
+	t.Cleanup(poolEfficiency.Reset)
+
+	printPoolEfficiency(sql.DBStats{OpenConnections: 10}, 0, 20)
+	out := captureStdout(t, func() {
+		printPoolEfficiency(sql.DBStats{OpenConnections: 10, MaxLifetimeClosed: 40}, 500, 20)
+	})
+	if !strings.Contains(out, "500 acquired, 40 opened") {
+		t.Errorf("efficiency line missing:\n%s", out)
+	}
+	if !strings.Contains(out, "raise --max-lifetime") {
+		t.Errorf("max-lifetime hint missing:\n%s", out)
+	}
+}
+
+func TestWarmupOpensMinIdle(t *testing.T) {
+	resetGlobals(t)
+	cfg.PoolSize, cfg.MinIdle = 10, 3
+
+	db, c := newFakeDB(t, proxyHandler("pxc-0", 1))
+	db.SetMaxOpenConns(cfg.PoolSize)
+	db.SetMaxIdleConns(cfg.MinIdle)
+
+	opened, err := warmupPool(context.Background(), db, cfg.MinIdle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened != 3 || atomic.LoadInt64(&c.nextID) != 3 {
+		t.Errorf("opened %d (%d physical connections), want 3", opened, atomic.LoadInt64(&c.nextID))
+	}
+	if s := db.Stats(); s.OpenConnections != 3 || s.Idle != 3 {
+		t.Errorf("after warmup open/idle = %d/%d, want 3/3", s.OpenConnections, s.Idle)
+	}
+
+	// --min-idle above --pool-size is capped to the pool
+	cfg.PoolSize = 2
+	small, _ := newFakeDB(t, proxyHandler("pxc-0", 1))
+	if opened, err := warmupPool(context.Background(), small, 5); err != nil || opened != 2 {
+		t.Errorf("opened %d (err %v), want 2", opened, err)
+	}
+}
+
+func TestBelowMinIdleAfterSettle(t *testing.T) {
+	resetGlobals(t)
+	cfg.PoolSize, cfg.MinIdle = 10, 3
+	warmedUp := time.Now()
+	settled := warmedUp.Add(minIdleSettle)
+
+	for _, tc := range []struct {
+		name  string
+		stats sql.DBStats
+		now   time.Time
+		want  bool
+	}{
+		{"settled below min-idle", sql.DBStats{OpenConnections: 4, Idle: 1}, settled, true},
+		{"still settling", sql.DBStats{OpenConnections: 4, Idle: 1}, warmedUp.Add(time.Second), false},
+		{"at min-idle", sql.DBStats{OpenConnections: 5, Idle: 3}, settled, false},
+		{"every slot in use", sql.DBStats{OpenConnections: 10, Idle: 0}, settled, false},
+	} {
+		if got := belowMinIdle(tc.stats, warmedUp, tc.now); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNodeNearMaxConnectionsShownRed(t *testing.T) {
+	resetGlobals(t)
+	cfg.MaxConnectionsWarn = 80
+	savedNoColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = savedNoColor })
+
+	busy := pxcHandler("pxc-0", 0, nil)
+	busyDB, _ := newFakeDB(t, func(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+		if strings.Contains(query, "processlist") {
+			return scalar(int64(190))
+		}
+		return busy(ctx, connID, query, args)
+	})
+	quietDB, _ := newFakeDB(t, pxcHandler("pxc-1", 0, nil))
+	setPXCNodeDB(t, "10.0.0.1:3306", busyDB)
+	setPXCNodeDB(t, "10.0.0.2:3306", quietDB)
+
+	near, err := fetchPXCNodeStatus(context.Background(), "10.0.0.1:3306")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := formatNodeConnections(near), color.RedString("190/200"); got != want {
+		t.Errorf("near max: got %q, want red %q", got, want)
+	}
+
+	fine, err := fetchPXCNodeStatus(context.Background(), "10.0.0.2:3306")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatNodeConnections(fine); got != "12/200" {
+		t.Errorf("well below max: got %q, want uncolored 12/200", got)
+	}
+
+	cfg.MaxConnectionsWarn = 0
+	if nearMaxConnections(near) {
+		t.Error("--max-connections-warn 0 still flags the node")
+	}
+}
+
+func TestPXCNodeDSNTimeoutFollowsStatsTimeout(t *testing.T) {
+	resetGlobals(t)
+	cfg.PXCUser, cfg.PXCPassword = "monitor", "secret"
+	cfg.StatsTimeout = 1500 * time.Millisecond
+
+	parsed, err := mysql.ParseDSN(pxcNodeDSN("10.0.0.1:3306"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Timeout != cfg.StatsTimeout || parsed.Addr != "10.0.0.1:3306" {
+		t.Errorf("DSN timeout %s addr %s, want %s on 10.0.0.1:3306", parsed.Timeout, parsed.Addr, cfg.StatsTimeout)
+	}
+}
+
+// blockUntilCancelled answers nothing until the fetch's context ends, like
+// an admin interface or node that accepted the connection and then hung
+func blockUntilCancelled(ctx context.Context, connID int64, query string, args []driver.NamedValue) fakeResult {
+	<-ctx.Done()
+	return fakeResult{err: ctx.Err()}
+}
+
+func TestSlowSourcesTimeOut(t *testing.T) {
+	resetGlobals(t)
+	cfg.StatsTimeout = 100 * time.Millisecond
+	const bound = 2 * time.Second
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	cfg.HAProxyStatsURL = srv.URL + "/stats"
+
+	start := time.Now()
+	_, err := refreshHAProxy(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("HAProxy: err = %v, want timed out", err)
+	}
+	if elapsed := time.Since(start); elapsed > bound {
+		t.Errorf("HAProxy fetch took %s", elapsed)
+	}
+
+	adminDB, _ := newFakeDB(t, blockUntilCancelled)
+	start = time.Now()
+	if _, err := refreshProxySQLPool(context.Background(), adminDB); err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("ProxySQL: err = %v, want timed out", err)
+	}
+	if elapsed := time.Since(start); elapsed > bound {
+		t.Errorf("ProxySQL fetch took %s", elapsed)
+	}
+
+	nodeDB, _ := newFakeDB(t, blockUntilCancelled)
+	setPXCNodeDB(t, "10.0.0.1:3306", nodeDB)
+	cfg.PXCNodes = []string{"10.0.0.1:3306"}
+	start = time.Now()
+	nodes := refreshPXC(context.Background())
+	if len(nodes) != 1 || !strings.Contains(nodes[0].ClusterStatus, "timed out after 100ms") {
+		t.Errorf("PXC: %+v, want a timed out node", nodes)
+	}
+	if elapsed := time.Since(start); elapsed > bound {
+		t.Errorf("PXC fetch took %s", elapsed)
+	}
+
+	snapshot.mu.RLock()
+	defer snapshot.mu.RUnlock()
+	if !strings.Contains(snapshot.HAProxyError, "timed out") || !strings.Contains(snapshot.ProxySQLPoolError, "timed out") {
+		t.Errorf("snapshot errors %q / %q, want timed out", snapshot.HAProxyError, snapshot.ProxySQLPoolError)
+	}
+}
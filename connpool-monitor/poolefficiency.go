@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// PoolEfficiency is derived pool health over one refresh interval
+type PoolEfficiency struct {
+	Acquisitions int64   // operations that took a connection from the pool
+	Opened       int64   // new physical connections
+	Closed       int64   // connections closed for max-idle, idle-time or max-lifetime
+	Lifetime     int64   // the part of Closed due to max-lifetime
+	ChurnPerMin  float64 // Closed scaled to a minute
+	ReuseRatio   float64 // percent of acquisitions served by an existing connection
+	WaitRatio    float64 // percent of acquisitions that had to wait
+}
+
+// PoolEfficiencyTracker keeps the previous db.Stats() sample so efficiency
+// can be computed from per-refresh deltas rather than lifetime totals
+type PoolEfficiencyTracker struct {
+	mu     sync.Mutex
+	prev   sql.DBStats
+	ops    int64
+	at     time.Time
+	primed bool
+}
+
+var poolEfficiency PoolEfficiencyTracker
+
+// Sample returns the efficiency since the previous call, ok is false on the
+// first call or after a SIGUSR1 reset made the counters go backwards
+func (t *PoolEfficiencyTracker) Sample(cur sql.DBStats, ops int64, now time.Time) (PoolEfficiency, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev, prevOps, prevAt, primed := t.prev, t.ops, t.at, t.primed
+	t.prev, t.ops, t.at, t.primed = cur, ops, now, true
+
+	if !primed || ops < prevOps || cur.WaitCount < prev.WaitCount || closedCount(cur) < closedCount(prev) {
+		return PoolEfficiency{}, false
+	}
+	return computePoolEfficiency(prev, cur, ops-prevOps, now.Sub(prevAt)), true
+}
+
+// Reset forgets the previous sample, e.g. after the stats are zeroed
+func (t *PoolEfficiencyTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.primed = false
+}
+
+func closedCount(s sql.DBStats) int64 {
+	return s.MaxIdleClosed + s.MaxIdleTimeClosed + s.MaxLifetimeClosed
+}
+
+// computePoolEfficiency derives churn, reuse and wait ratios from two
+// successive db.Stats() snapshots and the acquisitions made between them.
+// Every connection opened in the interval either is still open or was
+// closed, so opened = open connections delta + closed delta.
+func computePoolEfficiency(prev, cur sql.DBStats, acquisitions int64, elapsed time.Duration) PoolEfficiency {
+	e := PoolEfficiency{
+		Acquisitions: acquisitions,
+		Closed:       closedCount(cur) - closedCount(prev),
+		Lifetime:     cur.MaxLifetimeClosed - prev.MaxLifetimeClosed,
+	}
+	e.Opened = int64(cur.OpenConnections-prev.OpenConnections) + e.Closed
+	if e.Opened < 0 {
+		e.Opened = 0
+	}
+	if elapsed > 0 {
+		e.ChurnPerMin = float64(e.Closed) / elapsed.Minutes()
+	}
+	if acquisitions > 0 {
+		reused := acquisitions - e.Opened
+		if reused < 0 {
+			reused = 0
+		}
+		e.ReuseRatio = float64(reused) / float64(acquisitions) * 100
+		e.WaitRatio = float64(cur.WaitCount-prev.WaitCount) / float64(acquisitions) * 100
+	} else {
+		e.ReuseRatio = 100
+	}
+	return e
+}
+
+// unhealthyChurn reports whether connections are replaced faster than once
+// a minute per pool slot, the reconnect storm a too-low --max-lifetime causes
+func unhealthyChurn(e PoolEfficiency, poolSize int) bool {
+	return poolSize > 0 && e.ChurnPerMin > float64(poolSize)
+}
+
+// printPoolEfficiency shows churn, reuse and wait ratios for the last refresh
+func printPoolEfficiency(dbStats sql.DBStats, ops int64, poolSize int) {
+	e, ok := poolEfficiency.Sample(dbStats, ops, time.Now())
+	if !ok {
+		return
+	}
+
+	churn := fmt.Sprintf("%.1f/min", e.ChurnPerMin)
+	if unhealthyChurn(e, poolSize) {
+		churn = color.RedString(churn)
+	}
+	reuse := fmt.Sprintf("%.1f%%", e.ReuseRatio)
+	if e.ReuseRatio < 90 {
+		reuse = color.YellowString(reuse)
+	}
+	wait := fmt.Sprintf("%.1f%%", e.WaitRatio)
+	if e.WaitRatio > 10 {
+		wait = color.YellowString(wait)
+	}
+
+	fmt.Printf("  Efficiency (last refresh): churn %s, reuse %s, wait %s (%d acquired, %d opened)\n",
+		churn, reuse, wait, e.Acquisitions, e.Opened)
+	if unhealthyChurn(e, poolSize) && e.Lifetime*2 > e.Closed {
+		color.Red("  Connections are mostly closed by max-lifetime (%s): raise --max-lifetime to stop reconnecting through the proxy", cfg.MaxLifetime)
+	}
+	fmt.Println()
+}
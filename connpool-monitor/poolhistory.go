@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// poolHistorySize is the number of refreshes kept for the trend sparklines,
+// about two minutes at the 2s refresh interval
+const poolHistorySize = 60
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// PoolSample is one refresh worth of pool metrics
+type PoolSample struct {
+	Open      float64
+	InUse     float64
+	Waits     float64 // new waits since the previous sample
+	ErrorRate float64 // percent of operations that failed since the previous sample
+}
+
+// PoolHistory keeps a fixed-length ring of pool samples so a failover shows
+// up as a spike in the trend instead of a single number that's gone on the
+// next refresh
+type PoolHistory struct {
+	mu      sync.Mutex
+	samples []PoolSample
+	next    int
+	full    bool
+
+	// Cumulative counters at the previous sample, for per-refresh deltas
+	lastWaits  int64
+	lastOps    int64
+	lastFailed int64
+	primed     bool
+}
+
+var poolHistory PoolHistory
+
+// Sample records the current pool state
+func (h *PoolHistory) Sample(pools []*ProxyPool) {
+	dbStats := combinedDBStats(pools)
+
+	stats.mu.RLock()
+	ops := stats.TotalReads + stats.TotalWrites + stats.FailedReads + stats.FailedWrites
+	failed := stats.FailedReads + stats.FailedWrites
+	stats.mu.RUnlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := PoolSample{
+		Open:  float64(dbStats.OpenConnections),
+		InUse: float64(dbStats.InUse),
+	}
+	if h.primed {
+		// Counters go backwards after a SIGUSR1 reset; treat that as no change
+		if d := dbStats.WaitCount - h.lastWaits; d > 0 {
+			s.Waits = float64(d)
+		}
+		if dOps, dFailed := ops-h.lastOps, failed-h.lastFailed; dOps > 0 && dFailed > 0 {
+			s.ErrorRate = float64(dFailed) / float64(dOps) * 100
+		}
+	}
+	h.lastWaits, h.lastOps, h.lastFailed, h.primed = dbStats.WaitCount, ops, failed, true
+
+	h.add(s)
+}
+
+// add appends a sample to the ring. Callers must hold h.mu.
+func (h *PoolHistory) add(s PoolSample) {
+	if h.samples == nil {
+		h.samples = make([]PoolSample, poolHistorySize)
+	}
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Samples returns the recorded samples, oldest first
+func (h *PoolHistory) Samples() []PoolSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]PoolSample(nil), h.samples[:h.next]...)
+	}
+	out := make([]PoolSample, 0, len(h.samples))
+	out = append(out, h.samples[h.next:]...)
+	return append(out, h.samples[:h.next]...)
+}
+
+// Reset drops the recorded trend, e.g. after the stats are zeroed
+func (h *PoolHistory) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples = nil
+	h.next = 0
+	h.full = false
+	h.primed = false
+}
+
+// sparkline renders values as block characters scaled from zero to the
+// largest value, so a flat line at zero stays at the bottom
+func sparkline(values []float64) string {
+	max := 0.0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		idx := 0
+		if max > 0 && v > 0 {
+			idx = int(v / max * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// printPoolTrends prints a sparkline per pool metric with its latest value
+func printPoolTrends() {
+	samples := poolHistory.Samples()
+	if len(samples) < 2 {
+		return
+	}
+
+	series := []struct {
+		label  string
+		value  func(PoolSample) float64
+		format string
+	}{
+		{"Open", func(s PoolSample) float64 { return s.Open }, "%.0f"},
+		{"In Use", func(s PoolSample) float64 { return s.InUse }, "%.0f"},
+		{"Waits", func(s PoolSample) float64 { return s.Waits }, "%.0f/refresh"},
+		{"Error %", func(s PoolSample) float64 { return s.ErrorRate }, "%.1f%%"},
+	}
+
+	fmt.Printf("  Trend (last %d refreshes)\n", len(samples))
+	for _, ser := range series {
+		values := make([]float64, len(samples))
+		for i, s := range samples {
+			values[i] = ser.value(s)
+		}
+		fmt.Printf("  %-8s %s %s\n", ser.label, sparkline(values), fmt.Sprintf(ser.format, values[len(values)-1]))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// JoinerProgress remembers how far behind a rejoining node was at the last
+// refresh, so the catch-up rate can be estimated
+type JoinerProgress struct {
+	Lag int64
+	At  time.Time
+}
+
+// joinerProgress is keyed by node address and only touched by the display loop
+var joinerProgress = make(map[string]*JoinerProgress)
+
+// CatchUp is the estimated state-transfer progress of one non-Synced node
+type CatchUp struct {
+	Node     string
+	State    string
+	Lag      int64   // seqnos behind the most advanced node, -1 if unknown
+	Rate     float64 // seqnos closed per second since the last refresh
+	ETA      time.Duration
+	Stalled  bool // lag didn't shrink since the last refresh
+	Progress bool // a rate could be computed
+
+	RecvQueue        int
+	CertDepsDistance float64
+}
+
+// isTransferState reports whether a wsrep state means the node is sending or
+// receiving a state transfer or still catching up after one
+func isTransferState(state string) bool {
+	switch state {
+	case "Joiner", "Joined", "Donor", "Donor/Desynced":
+		return true
+	}
+	return false
+}
+
+// estimateCatchUp compares each non-Synced node's wsrep_last_committed with
+// the most advanced node and with its own lag at the previous refresh
+func estimateCatchUp(statuses []PXCNodeStatus, now time.Time) []CatchUp {
+	var head int64
+	for _, s := range statuses {
+		if s.LastCommitted > head {
+			head = s.LastCommitted
+		}
+	}
+
+	var out []CatchUp
+	seen := make(map[string]bool)
+	for _, s := range statuses {
+		if !isTransferState(s.LocalState) {
+			continue
+		}
+		seen[s.Address] = true
+
+		name := s.NodeName
+		if name == "" {
+			name = s.Address
+		}
+		c := CatchUp{
+			Node:             name,
+			State:            s.LocalState,
+			Lag:              -1,
+			RecvQueue:        s.RecvQueue,
+			CertDepsDistance: s.CertDepsDistance,
+		}
+
+		if s.LastCommitted > 0 && head > 0 {
+			c.Lag = head - s.LastCommitted
+
+			if prev, ok := joinerProgress[s.Address]; ok {
+				elapsed := now.Sub(prev.At).Seconds()
+				closed := prev.Lag - c.Lag
+				switch {
+				case elapsed <= 0:
+				case closed > 0:
+					c.Rate = float64(closed) / elapsed
+					c.ETA = time.Duration(float64(c.Lag) / c.Rate * float64(time.Second))
+					c.Progress = true
+				case c.Lag > 0:
+					c.Stalled = true
+				}
+			}
+			joinerProgress[s.Address] = &JoinerProgress{Lag: c.Lag, At: now}
+		}
+		out = append(out, c)
+	}
+
+	// Forget nodes that finished syncing so a later SST starts fresh
+	for addr := range joinerProgress {
+		if !seen[addr] {
+			delete(joinerProgress, addr)
+		}
+	}
+	return out
+}
+
+// printCatchUp shows state-transfer progress for Donor/Joiner nodes
+func printCatchUp(statuses []PXCNodeStatus) {
+	catchUps := estimateCatchUp(statuses, time.Now())
+	if len(catchUps) == 0 {
+		return
+	}
+
+	fmt.Println("  State transfer:")
+	for _, c := range catchUps {
+		line := fmt.Sprintf("    %-12s %-15s", c.Node, c.State)
+		switch {
+		case strings.HasPrefix(c.State, "Donor"):
+			color.Yellow("%s serving state transfer to a joiner", line)
+		case c.Lag < 0:
+			color.Yellow("%s waiting for SST, no seqno yet", line)
+		case c.Lag == 0:
+			color.Green("%s caught up with the cluster", line)
+		case c.Stalled:
+			color.Red("%s %d seqnos behind, no progress since last refresh%s", line, c.Lag, queueDetail(c))
+		case c.Progress:
+			color.Yellow("%s %d seqnos behind, closing at %.0f/s, ETA %s%s", line, c.Lag, c.Rate, c.ETA.Round(time.Second), queueDetail(c))
+		default:
+			color.Yellow("%s %d seqnos behind%s", line, c.Lag, queueDetail(c))
+		}
+	}
+	fmt.Println()
+}
+
+// queueDetail describes the apply backlog of a catching-up node: a growing
+// receive queue with a low cert deps distance means it applies serially
+func queueDetail(c CatchUp) string {
+	return fmt.Sprintf(" (recv q %d, cert deps %.1f)", c.RecvQueue, c.CertDepsDistance)
+}
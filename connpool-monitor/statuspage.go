@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// DashboardSnapshot holds the most recently fetched proxy and PXC data so the
+// HTML status page renders exactly what the terminal shows without querying
+// the proxies and nodes a second time. The dashboard, --output json or, under
+// --quiet, runSnapshotLoop keeps it current.
+type DashboardSnapshot struct {
+	mu sync.RWMutex
+
+	HAProxyBackends []HAProxyBackend
+	HAProxyError    string
+
+	ProxySQLServers     []ProxySQLServer
+	ProxySQLServerError string
+	ProxySQLPool        []ProxySQLConnPool
+	ProxySQLPoolError   string
+
+	PXCNodes []PXCNodeStatus
+}
+
+var snapshot DashboardSnapshot
+
+func (d *DashboardSnapshot) setHAProxy(backends []HAProxyBackend, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.HAProxyBackends = backends
+	d.HAProxyError = errorString(err)
+}
+
+func (d *DashboardSnapshot) setProxySQLServers(servers []ProxySQLServer, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ProxySQLServers = servers
+	d.ProxySQLServerError = errorString(err)
+}
+
+func (d *DashboardSnapshot) setProxySQLPool(pool []ProxySQLConnPool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ProxySQLPool = pool
+	d.ProxySQLPoolError = errorString(err)
+}
+
+func (d *DashboardSnapshot) setPXC(nodes []PXCNodeStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.PXCNodes = nodes
+}
+
+// refreshSnapshot fetches everything the status page shows from the proxies
+// and PXC nodes, each fetch bounded by --stats-timeout
+func refreshSnapshot(ctx context.Context) {
+	if showHAProxyPanel() {
+		refreshHAProxy(ctx)
+	}
+	if showProxySQLPanel() {
+		adminDB, err := sql.Open("mysql", proxySQLAdminDSN())
+		if err != nil {
+			snapshot.setProxySQLServers(nil, err)
+			snapshot.setProxySQLPool(nil, err)
+		} else {
+			refreshProxySQLServers(ctx, adminDB)
+			refreshProxySQLPool(ctx, adminDB)
+			adminDB.Close()
+		}
+	}
+	if len(cfg.PXCNodes) > 0 {
+		refreshPXC(ctx)
+	}
+}
+
+// runSnapshotLoop keeps the snapshot current while no dashboard is fetching
+// for it, so the status page still shows the proxies and nodes under --quiet
+func runSnapshotLoop(ctx context.Context) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshSnapshot(ctx)
+		}
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// statusPageData is the view model rendered by statusPageTemplate
+type statusPageData struct {
+	Mode      string
+	Time      string
+	PoolSize  int
+	Pool      sql.DBStats
+	Endpoints []endpointView
+
+	TotalReads        int64
+	TotalWrites       int64
+	FailedReads       int64
+	FailedWrites      int64
+	AvgReadLatency    time.Duration
+	AvgWriteLatency   time.Duration
+	MinReadLatency    time.Duration
+	MaxReadLatency    time.Duration
+	MinWriteLatency   time.Duration
+	MaxWriteLatency   time.Duration
+	AvgAcquireLatency time.Duration
+	MaxAcquireLatency time.Duration
+	LastBackendNode   string
+	Routing           []routingView
+	WriteNodes        int
+	Errors            []ConnectionError
+	ErrorSummary      []ErrorGroup
+
+	ShowHAProxy         bool
+	ShowProxySQL        bool
+	HAProxyBackends     []HAProxyBackend
+	HAProxyError        string
+	ProxySQLServers     []ProxySQLServer
+	ProxySQLServerError string
+	ProxySQLPool        []ProxySQLConnPool
+	ProxySQLPoolError   string
+	PXCNodes            []pxcNodeView
+}
+
+type endpointView struct {
+	Addr  string
+	Stats sql.DBStats
+}
+
+// pxcNodeView is one row of the PXC table, with flow control shown as the
+// per-refresh pause fraction and trend the terminal shows rather than the
+// cumulative counter
+type pxcNodeView struct {
+	PXCNodeStatus
+	FlowControlText  string
+	FlowControlClass string
+}
+
+func newPXCNodeView(s PXCNodeStatus) pxcNodeView {
+	v := pxcNodeView{PXCNodeStatus: s, FlowControlText: s.FlowControl}
+	if h := flowControlHistory.Get(s.Address); len(h.Fractions) > 0 {
+		v.FlowControlText = h.Text()
+		v.FlowControlClass = h.Level()
+	} else if s.FlowControl == "OFF" || s.FlowControl == "0" {
+		v.FlowControlClass = "ok"
+	} else if s.FlowControl != "" {
+		v.FlowControlClass = "warn"
+	}
+	return v
+}
+
+// routingView is one row of the read/write routing table
+type routingView struct {
+	NodeRouting
+	ServerIDLabel string
+	ReadShare     string
+	WriteShare    string
+}
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"nearMax": nearMaxConnections,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="2">
+<title>Connection Pool Monitor</title>
+<style>
+body { font-family: monospace; background: #111; color: #ddd; margin: 1.5em; }
+h1 { font-size: 1.2em; }
+h2 { font-size: 1em; margin-top: 1.5em; border-bottom: 1px solid #444; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+th, td { padding: 2px 10px; text-align: left; border-bottom: 1px solid #333; }
+th { color: #aaa; }
+.ok { color: #4c4; } .warn { color: #cc4; } .bad { color: #e44; }
+</style>
+</head>
+<body>
+<h1>CONNECTION POOL MONITOR - {{.Mode}} | {{.Time}}</h1>
+
+<h2>[CONNECTION POOL STATUS]</h2>
+<table id="pool">
+<tr><th>Metric</th><th>Value</th><th>Metric</th><th>Value</th></tr>
+<tr><td>Pool Size</td><td>{{.Pool.OpenConnections}}/{{.PoolSize}}</td><td>In Use</td><td>{{.Pool.InUse}}</td></tr>
+<tr><td>Idle</td><td>{{.Pool.Idle}}</td><td>Wait Count</td><td>{{.Pool.WaitCount}}</td></tr>
+<tr><td>Max Idle Closed</td><td>{{.Pool.MaxIdleClosed}}</td><td>Max Lifetime Closed</td><td>{{.Pool.MaxLifetimeClosed}}</td></tr>
+<tr><td>Total Reads</td><td>{{.TotalReads}}</td><td>Failed Reads</td><td class="{{if .FailedReads}}bad{{else}}ok{{end}}">{{.FailedReads}}</td></tr>
+<tr><td>Total Writes</td><td>{{.TotalWrites}}</td><td>Failed Writes</td><td class="{{if .FailedWrites}}bad{{else}}ok{{end}}">{{.FailedWrites}}</td></tr>
+<tr><td>Avg Read Latency</td><td>{{.AvgReadLatency}}</td><td>Avg Write Latency</td><td>{{.AvgWriteLatency}}</td></tr>
+<tr><td>Min/Max Read</td><td>{{.MinReadLatency}} / {{.MaxReadLatency}}</td><td>Min/Max Write</td><td>{{.MinWriteLatency}} / {{.MaxWriteLatency}}</td></tr>
+<tr><td>Acquire Avg/Max</td><td>{{.AvgAcquireLatency}} / {{.MaxAcquireLatency}}</td><td>Wait Duration</td><td>{{.Pool.WaitDuration}}</td></tr>
+<tr><td>Last Backend</td><td>{{.LastBackendNode}}</td><td></td><td></td></tr>
+</table>
+
+{{if gt (len .Endpoints) 1}}
+<h2>[PROXY ENDPOINTS]</h2>
+<table id="endpoints">
+<tr><th>Endpoint</th><th>Open</th><th>In Use</th><th>Idle</th><th>Wait Count</th><th>Wait Duration</th></tr>
+{{range .Endpoints}}<tr><td>{{.Addr}}</td><td>{{.Stats.OpenConnections}}</td><td>{{.Stats.InUse}}</td><td>{{.Stats.Idle}}</td><td>{{.Stats.WaitCount}}</td><td>{{.Stats.WaitDuration}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .ShowHAProxy}}
+<h2>[HAPROXY BACKEND STATUS]</h2>
+{{if .HAProxyError}}<p class="bad">Error fetching HAProxy stats: {{.HAProxyError}}</p>{{else}}
+<table id="haproxy-backends">
+<tr><th>Backend</th><th>Status</th><th>Address</th><th>Curr Conn</th><th>Sessions</th><th>Check</th><th>Last Change</th></tr>
+{{range .HAProxyBackends}}<tr><td>{{.Name}}</td><td class="{{if eq .Status "UP"}}ok{{else if eq .Status "MAINT"}}warn{{else}}bad{{end}}">{{.Status}}</td><td>{{.Addr}}</td><td>{{.CurrentConn}}/{{.MaxConn}}</td><td>{{.Sessions}}</td><td>{{.CheckStatus}}</td><td>{{.LastChange}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+
+{{if .ShowProxySQL}}
+<h2>[PROXYSQL STATUS]</h2>
+{{if .ProxySQLServerError}}<p class="bad">Error fetching server status: {{.ProxySQLServerError}}</p>{{else}}
+<table id="proxysql-servers">
+<tr><th>HG</th><th>Host</th><th>Port</th><th>Status</th><th>Weight</th><th>Max Conn</th><th>Latency</th><th>Runtime</th></tr>
+{{range .ProxySQLServers}}<tr><td>{{.HostgroupID}}</td><td>{{.Hostname}}</td><td>{{.Port}}</td><td class="{{if eq .Status "ONLINE"}}ok{{else if eq .Status "OFFLINE_SOFT"}}warn{{else}}bad{{end}}">{{.Status}}</td><td>{{.Weight}}</td><td>{{.MaxConns}}</td><td>{{.MaxLatencyMs}}ms</td><td class="{{if .Drift}}bad{{else}}ok{{end}}">{{if .Drift}}config not loaded ({{.Drift}}){{else}}loaded{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .ProxySQLPoolError}}<p class="bad">Error fetching connection pool stats: {{.ProxySQLPoolError}}</p>{{else}}
+<table id="proxysql-pool">
+<tr><th>HG</th><th>Server</th><th>Status</th><th>Used</th><th>Free</th><th>OK</th><th>Err</th><th>Queries</th><th>Latency</th></tr>
+{{range .ProxySQLPool}}<tr><td>{{.HostgroupID}}</td><td>{{.SrvHost}}:{{.SrvPort}}</td><td>{{.Status}}</td><td>{{.ConnUsed}}</td><td>{{.ConnFree}}</td><td>{{.ConnOK}}</td><td class="{{if .ConnErr}}bad{{end}}">{{.ConnErr}}</td><td>{{.Queries}}</td><td>{{.LatencyUs}}us</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+
+<h2>[PXC CLUSTER STATUS]</h2>
+{{if .PXCNodes}}
+<table id="pxc-nodes">
+<tr><th>Node</th><th>Address</th><th>State</th><th>Cluster</th><th>Size</th><th>Ready</th><th>Flow Ctrl</th><th>Recv Q</th><th>Send Q</th><th>Conns</th></tr>
+{{range .PXCNodes}}<tr><td>{{.NodeName}}</td><td>{{.Address}}</td><td class="{{if eq .LocalState "Synced"}}ok{{else if or (eq .LocalState "Donor") (eq .LocalState "Joiner")}}warn{{else}}bad{{end}}">{{.LocalState}}</td><td>{{.ClusterStatus}}</td><td>{{.ClusterSize}}</td><td>{{.ReadyStatus}}</td><td class="{{.FlowControlClass}}">{{.FlowControlText}}</td><td>{{.RecvQueue}}</td><td>{{.SendQueue}}</td><td class="{{if nearMax .PXCNodeStatus}}bad{{end}}">{{.Connections}}{{if gt .MaxConnections 0}}/{{.MaxConnections}}{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p class="warn">No PXC node data. Use --pxc-nodes to specify nodes.</p>{{end}}
+
+{{if .Routing}}
+<h2>[READ/WRITE ROUTING]</h2>
+<table id="routing">
+<tr><th>Backend</th><th>Server ID</th><th>Reads</th><th>Read %</th><th>Writes</th><th>Write %</th></tr>
+{{range .Routing}}<tr><td>{{.Node}}</td><td>{{.ServerIDLabel}}</td><td>{{.Reads}}</td><td>{{.ReadShare}}</td><td>{{.Writes}}</td><td>{{.WriteShare}}</td></tr>
+{{end}}</table>
+{{if gt .WriteNodes 1}}<p class="bad">WRITE SPLIT VIOLATION: writes landed on {{.WriteNodes}} nodes (expected 1 for single-primary routing)</p>{{end}}
+{{end}}
+
+{{if .Errors}}
+<h2>[RECENT CONNECTION ERRORS]</h2>
+<table id="errors">
+<tr><th>Time</th><th>Operation</th><th>Node</th><th>Error</th></tr>
+{{range .Errors}}<tr><td>{{.Timestamp.Format "15:04:05"}}</td><td class="bad">{{.Operation}}</td><td>{{.Node}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .ErrorSummary}}
+<h2>[ERROR SUMMARY]</h2>
+<table id="error-summary">
+<tr><th>Count</th><th>First Seen</th><th>Last Seen</th><th>Error</th></tr>
+{{range .ErrorSummary}}<tr><td class="bad">{{.Count}}</td><td>{{.FirstSeen.Format "15:04:05"}}</td><td>{{.LastSeen.Format "15:04:05"}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// runStatusServer serves the HTML status page until ctx is cancelled
+func runStatusServer(ctx context.Context, pools []*ProxyPool) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		handleStatusPage(w, pools)
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		handleMetrics(w, pools)
+	})
+	mux.HandleFunc("/version", handleVersion)
+
+	server := &http.Server{Addr: cfg.HTTPAddr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		color.Red("Status page server failed: %v", err)
+	}
+}
+
+func handleStatusPage(w http.ResponseWriter, pools []*ProxyPool) {
+	data := statusPageData{
+		Mode:         "HAProxy",
+		Time:         time.Now().Format("15:04:05"),
+		PoolSize:     cfg.PoolSize * len(pools),
+		Pool:         combinedDBStats(pools),
+		ShowHAProxy:  showHAProxyPanel(),
+		ShowProxySQL: showProxySQLPanel(),
+	}
+	switch {
+	case cfg.Both:
+		data.Mode = "HAProxy + ProxySQL"
+	case cfg.UseProxySQL:
+		data.Mode = "ProxySQL"
+	}
+	for _, p := range pools {
+		data.Endpoints = append(data.Endpoints, endpointView{Addr: p.Addr, Stats: p.Stats()})
+	}
+
+	stats.mu.RLock()
+	data.TotalReads = stats.TotalReads
+	data.TotalWrites = stats.TotalWrites
+	data.FailedReads = stats.FailedReads
+	data.FailedWrites = stats.FailedWrites
+	data.AvgReadLatency = stats.AvgReadLatency
+	data.AvgWriteLatency = stats.AvgWriteLatency
+	data.MinReadLatency = stats.MinReadLatency
+	data.MaxReadLatency = stats.MaxReadLatency
+	data.MinWriteLatency = stats.MinWriteLatency
+	data.MaxWriteLatency = stats.MaxWriteLatency
+	data.AvgAcquireLatency = stats.AvgAcquireLatency
+	data.MaxAcquireLatency = stats.MaxAcquireLatency
+	data.LastBackendNode = stats.LastBackendNode
+	var totalReads, totalWrites int64
+	for _, r := range stats.Routing {
+		totalReads += r.Reads
+		totalWrites += r.Writes
+	}
+	for _, r := range sortedRouting() {
+		data.Routing = append(data.Routing, routingView{
+			NodeRouting:   r,
+			ServerIDLabel: formatServerID(r.ServerID),
+			ReadShare:     sharePercent(r.Reads, totalReads),
+			WriteShare:    sharePercent(r.Writes, totalWrites),
+		})
+	}
+	data.WriteNodes = writeNodeCount()
+	start := 0
+	if len(stats.ConnectionErrors) > 10 {
+		start = len(stats.ConnectionErrors) - 10
+	}
+	data.Errors = append([]ConnectionError(nil), stats.ConnectionErrors[start:]...)
+	stats.mu.RUnlock()
+	data.ErrorSummary = errorSummary()
+
+	snapshot.mu.RLock()
+	data.HAProxyBackends = snapshot.HAProxyBackends
+	data.HAProxyError = snapshot.HAProxyError
+	data.ProxySQLServers = snapshot.ProxySQLServers
+	data.ProxySQLServerError = snapshot.ProxySQLServerError
+	data.ProxySQLPool = snapshot.ProxySQLPool
+	data.ProxySQLPoolError = snapshot.ProxySQLPoolError
+	nodes := snapshot.PXCNodes
+	snapshot.mu.RUnlock()
+	for _, n := range nodes {
+		data.PXCNodes = append(data.PXCNodes, newPXCNodeView(n))
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusPageTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleMetrics serves the snapshot as the same JSONReport --output json
+// writes, for scraping the monitor while it runs
+func handleMetrics(w http.ResponseWriter, pools []*ProxyPool) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshotJSONReport(pools)); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
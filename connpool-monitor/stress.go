@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// StressState tracks the connections held by --stress-exhaust and when the
+// pool first started making callers wait
+type StressState struct {
+	mu sync.Mutex
+
+	held   int64
+	target int64
+
+	baselineWaits int64
+	haveBaseline  bool
+	firstWaitAt   time.Time
+	heldAtWait    int64
+}
+
+var stress StressState
+
+// runStressExhaust ramps up connections held open in an idle transaction
+// until the pool is one past full, then holds them until ctx is cancelled
+func runStressExhaust(ctx context.Context, p *ProxyPool) {
+	target := cfg.PoolSize + 1
+	atomic.AddInt64(&stress.target, int64(target))
+
+	interval := cfg.StressRamp / time.Duration(target)
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for i := 0; i < target; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			holdConnection(ctx, p.DB)
+		}()
+	}
+}
+
+// holdConnection takes a connection, opens a transaction and keeps it idle
+// until ctx is cancelled. The final holder blocks in db.Conn because the
+// pool is already full, which is what drives WaitCount up.
+func holdConnection(ctx context.Context, db *sql.DB) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			recordError("stress_conn", err, "")
+		}
+		return
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		recordError("stress_begin", err, "")
+		return
+	}
+	// Touch the server so the transaction is really open on the backend
+	if _, err := tx.ExecContext(ctx, "SELECT 1"); err != nil {
+		recordError("stress_hold", err, "")
+		tx.Rollback()
+		return
+	}
+
+	atomic.AddInt64(&stress.held, 1)
+	defer atomic.AddInt64(&stress.held, -1)
+
+	<-ctx.Done()
+	tx.Rollback()
+}
+
+func printStressStats(pools []*ProxyPool) {
+	bold := color.New(color.Bold)
+	bold.Println("[STRESS: POOL EXHAUSTION]")
+	fmt.Println(strings.Repeat("-", 79))
+
+	dbStats := combinedDBStats(pools)
+	held := atomic.LoadInt64(&stress.held)
+	target := atomic.LoadInt64(&stress.target)
+
+	stress.mu.Lock()
+	if !stress.haveBaseline {
+		stress.baselineWaits = dbStats.WaitCount
+		stress.haveBaseline = true
+	}
+	if stress.firstWaitAt.IsZero() && dbStats.WaitCount > stress.baselineWaits {
+		stress.firstWaitAt = time.Now()
+		stress.heldAtWait = held
+	}
+	firstWaitAt, heldAtWait := stress.firstWaitAt, stress.heldAtWait
+	stress.mu.Unlock()
+
+	stats.mu.RLock()
+	timeouts := stats.ErrorCategories[ErrCategoryTimeout]
+	stats.mu.RUnlock()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Metric", "Value", "Metric", "Value"})
+	table.SetBorder(false)
+	table.SetColumnSeparator("|")
+
+	table.Append([]string{
+		"Held Connections", fmt.Sprintf("%d/%d", held, target),
+		"Pool Size", fmt.Sprintf("%d", cfg.PoolSize*len(pools)),
+	})
+	table.Append([]string{
+		"Wait Count", formatErrorCount(dbStats.WaitCount),
+		"Wait Duration", dbStats.WaitDuration.String(),
+	})
+	table.Append([]string{
+		"Timeouts", formatErrorCount(timeouts),
+		"In Use", fmt.Sprintf("%d", dbStats.InUse),
+	})
+	table.Render()
+
+	if firstWaitAt.IsZero() {
+		color.Green("  Pool not yet exhausted - no callers waiting")
+	} else {
+		color.Red("  Pool exhausted: callers waiting since %s (%d connections held)",
+			firstWaitAt.Format("15:04:05"), heldAtWait)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// minIdleSettle is how long after warmup the pool gets before an idle count
+// below --min-idle is reported
+const minIdleSettle = 10 * time.Second
+
+// warmupPool opens up to n connections at once and returns them to the pool
+// so they stay idle. database/sql opens connections lazily, so without this
+// the pool starts empty where HikariCP would fill minimumIdle up front.
+// It returns how many connections were opened.
+func warmupPool(ctx context.Context, db *sql.DB, n int) (int, error) {
+	if n > cfg.PoolSize {
+		n = cfg.PoolSize
+	}
+
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	// Hold every connection until all are open, otherwise the pool would
+	// hand the same one back each time
+	for len(conns) < n {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return len(conns), err
+		}
+		if err := conn.PingContext(ctx); err != nil {
+			conn.Close()
+			return len(conns), err
+		}
+		conns = append(conns, conn)
+	}
+	return len(conns), nil
+}
+
+// belowMinIdle reports whether a settled pool has fewer idle connections than
+// --min-idle while it still has room to open more. A pool with every slot in
+// use can't keep connections idle under HikariCP either.
+func belowMinIdle(s sql.DBStats, warmedUp, now time.Time) bool {
+	if cfg.MinIdle <= 0 || now.Sub(warmedUp) < minIdleSettle {
+		return false
+	}
+	return s.Idle < cfg.MinIdle && s.OpenConnections < cfg.PoolSize
+}
+
+// printMinIdleCheck warns for each pool whose idle count fell below
+// --min-idle. database/sql only keeps connections that were returned to it
+// and never replaces the ones closed by --idle-timeout or --max-lifetime.
+func printMinIdleCheck(pools []*ProxyPool) {
+	now := time.Now()
+	warned := false
+	for _, p := range pools {
+		s := p.Stats()
+		if !belowMinIdle(s, p.WarmedUp, now) {
+			continue
+		}
+		color.Yellow("  %s: %d idle < --min-idle %d; database/sql doesn't refill idle connections like HikariCP's minimumIdle",
+			p.Addr, s.Idle, cfg.MinIdle)
+		warned = true
+	}
+	if warned {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+// Shared build info for every service's --version and /version.
+//
+// This file is the canonical copy. dr-dashboard and its eks/ and on-prem/
+// modules carry byte-identical copies, refreshed with `go generate` there
+// (or `make sync-buildinfo` in dr-dashboard); their tests fail when a copy
+// drifts from this one.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+)
+
+// Build metadata, set at link time (see `make build`):
+//
+//	go build -ldflags "-X main.gitCommit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	gitCommit = "dev"
+	buildTime = "dev"
+)
+
+// BuildInfo identifies the running binary
+type BuildInfo struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+func buildInfo() BuildInfo {
+	return BuildInfo{
+		Commit:    gitCommit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// versionString is what --version prints
+func versionString() string {
+	b := buildInfo()
+	return fmt.Sprintf("commit %s, built %s, %s", b.Commit, b.BuildTime, b.GoVersion)
+}
+
+// handleVersion serves buildInfo as JSON, so the deployed revision can be
+// confirmed during an incident
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(buildInfo()); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
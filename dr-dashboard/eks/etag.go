@@ -0,0 +1,47 @@
+package main
+
+// Shared with eks/ and on-prem/, which carry byte-identical copies; see
+// schema.go.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// hashScenarios returns a short content hash of the loaded scenario data,
+// used as the base of the scenarios API ETag
+func hashScenarios(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// responseETag derives the ETag for one scenarios response. The sort and
+// tag options change the body, so they are folded into the tag. It is weak
+// because withGzip may send the same data gzipped or as identity, and a
+// strong ETag must differ between the two byte representations.
+func responseETag(dataHash string, r *http.Request) string {
+	q := r.URL.Query()
+	sum := sha256.Sum256([]byte(q.Get("sort") + "\x00" + q.Get("tag")))
+	return fmt.Sprintf(`W/"%s-%s"`, dataHash, hex.EncodeToString(sum[:4]))
+}
+
+// etagMatches reports whether the request's If-None-Match lists etag, using
+// the weak comparison If-None-Match calls for
+func etagMatches(r *http.Request, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+// Shared with eks/ and on-prem/, which carry byte-identical copies; see
+// schema.go.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinSize is the smallest response body worth compressing
+const gzipMinSize = 1024
+
+// gzipResponseWriter buffers a handler's response so withGzip can decide
+// whether it's large enough to compress
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	g.status = status
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	return g.buf.Write(b)
+}
+
+// withGzip compresses responses of at least gzipMinSize bytes for clients
+// that send Accept-Encoding: gzip; smaller responses are sent as-is
+func withGzip(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			next(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next(gw, r)
+
+		if gw.buf.Len() < gzipMinSize || w.Header().Get("Content-Encoding") != "" {
+			w.WriteHeader(gw.status)
+			w.Write(gw.buf.Bytes())
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(gw.status)
+
+		zw := gzip.NewWriter(w)
+		if _, err := zw.Write(gw.buf.Bytes()); err != nil {
+			log.Printf("Error writing gzip response: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			log.Printf("Error writing gzip response: %v", err)
+		}
+	}
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		// "gzip;q=0" explicitly refuses it
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -9,30 +10,47 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
+// Copies of the top-level dashboard's shared files; see ../schema.go
+//go:generate cp ../schema.go ../disaster_scenarios.schema.json ../paths.go ../related.go ../etag.go ../tags.go ../gzip.go ../testresults.go ../sort.go .
+
+// buildinfo.go is a copy of the shared one; see ../../connpool-monitor/buildinfo.go
+//go:generate cp ../../connpool-monitor/buildinfo.go .
+
+// default_scenarios.json is a copy of the testing framework's catalog
+//go:generate cp ../../testing/eks/disaster_scenarios/disaster_scenarios.json default_scenarios.json
+
 const environment = "eks"
 
 // DisasterScenario represents a single disaster recovery scenario
 // Data source: ../../testing/eks/disaster_scenarios/disaster_scenarios.json
 // This maintains single source of truth with the testing framework
 type DisasterScenario struct {
-	Scenario              string  `json:"scenario"`
-	PrimaryRecoveryMethod string  `json:"primary_recovery_method"`
-	AlternateFallback     string  `json:"alternate_fallback"`
-	DetectionSignals      string  `json:"detection_signals"`
-	RTOTarget             string  `json:"rto_target"`
-	RPOTarget             string  `json:"rpo_target"`
-	MTTRExpected          string  `json:"mttr_expected"`
-	ExpectedDataLoss      string  `json:"expected_data_loss"`
-	Likelihood            string  `json:"likelihood"`
-	BusinessImpact        string  `json:"business_impact"`
-	AffectedComponents    string  `json:"affected_components"`
-	NotesAssumptions      string  `json:"notes_assumptions"`
-	TestEnabled           bool    `json:"test_enabled"`
-	TestDescription       string  `json:"test_description"`
-	TestFile              *string `json:"test_file"`
-	RecoveryProcessFile   string  `json:"recovery_process_file,omitempty"`
+	Scenario              string   `json:"scenario"`
+	PrimaryRecoveryMethod string   `json:"primary_recovery_method"`
+	AlternateFallback     string   `json:"alternate_fallback"`
+	DetectionSignals      string   `json:"detection_signals"`
+	RTOTarget             string   `json:"rto_target"`
+	RPOTarget             string   `json:"rpo_target"`
+	MTTRExpected          string   `json:"mttr_expected"`
+	ExpectedDataLoss      string   `json:"expected_data_loss"`
+	Likelihood            string   `json:"likelihood"`
+	BusinessImpact        string   `json:"business_impact"`
+	AffectedComponents    string   `json:"affected_components"`
+	Tags                  []string `json:"tags,omitempty"`
+	Related               []string `json:"related,omitempty"`
+	NotesAssumptions      string   `json:"notes_assumptions"`
+	TestEnabled           bool     `json:"test_enabled"`
+	TestDescription       string   `json:"test_description"`
+	TestFile              *string  `json:"test_file"`
+	RecoveryProcessFile   string   `json:"recovery_process_file,omitempty"`
+
+	// Merged from test_results.json; nil until the scenario's test has run
+	LastRun         *time.Time `json:"last_run,omitempty"`
+	LastResult      *string    `json:"last_result,omitempty"`
+	DurationSeconds *float64   `json:"duration_seconds,omitempty"`
 }
 
 // DiscardedScenario represents a scenario that has no recovery process documentation
@@ -53,8 +71,16 @@ type ScenarioResponse struct {
 	DiscardedScenarios []DiscardedScenario `json:"discarded_scenarios"`
 }
 
+// RelatedResponse is the "see also" list for one scenario
+type RelatedResponse struct {
+	Environment string             `json:"environment"`
+	Scenario    string             `json:"scenario"`
+	Related     []DisasterScenario `json:"related"`
+}
+
 var scenarios []DisasterScenario
 var discardedScenarios []DiscardedScenario
+var scenariosHash string
 var baseDir string
 var dataDir string
 var staticDir string
@@ -97,7 +123,9 @@ func main() {
 
 	// Setup HTTP handlers
 	http.HandleFunc("/", handleIndex)
-	http.HandleFunc("/api/scenarios", handleScenarios)
+	http.HandleFunc("/version", handleVersion)
+	http.HandleFunc("/api/scenarios", withGzip(handleScenarios))
+	http.HandleFunc("/api/scenario/related", handleRelated)
 	http.HandleFunc("/api/recovery-process", handleRecoveryProcess)
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(staticDir))))
 
@@ -114,6 +142,7 @@ func main() {
 // loadScenarios reads disaster scenarios from the testing framework's JSON file
 // Local mode: ../../testing/eks/disaster_scenarios/disaster_scenarios.json
 // Container mode: $DATA_DIR/scenarios/disaster_scenarios.json
+// Either falls back to the embedded default when the file can't be read.
 func loadScenarios() error {
 	var jsonPath string
 	if dataDir != "" {
@@ -125,9 +154,13 @@ func loadScenarios() error {
 	}
 
 	log.Printf("Loading scenarios from: %s", jsonPath)
-	data, err := os.ReadFile(jsonPath)
+	data, err := readScenariosFile(jsonPath)
 	if err != nil {
-		return fmt.Errorf("failed to read %s scenarios from %s: %w", environment, jsonPath, err)
+		return err
+	}
+
+	if err := validateScenariosJSON(data); err != nil {
+		return fmt.Errorf("%s scenarios in %s failed schema validation:\n%w", environment, jsonPath, err)
 	}
 
 	var scenariosFile DisasterScenariosFile
@@ -144,10 +177,44 @@ func loadScenarios() error {
 		scenarios[i].RecoveryProcessFile = filename
 	}
 
+	results, err := loadTestResults(filepath.Dir(jsonPath))
+	if err != nil {
+		// Test results are informational; never block the dashboard on them
+		log.Printf("Warning: ignoring %s test results: %v", environment, err)
+	}
+	mergeTestResults(scenarios, results)
+	applyTags(scenarios)
+	checkRelated(environment, scenarios)
+
+	scenariosHash, err = hashScenarios(ScenarioResponse{Scenarios: scenarios, DiscardedScenarios: discardedScenarios})
+	if err != nil {
+		return fmt.Errorf("failed to hash %s scenarios: %w", environment, err)
+	}
+
 	log.Printf("✅ Loaded %d scenarios for %s", len(scenarios), environment)
 	return nil
 }
 
+// defaultScenarios is a baseline copy of the catalog so the binary still
+// starts outside the repo layout and without DATA_DIR
+//
+//go:embed default_scenarios.json
+var defaultScenarios []byte
+
+// readScenariosFile reads jsonPath, falling back to the embedded default
+// when it can't be read. The external file always wins when present.
+func readScenariosFile(jsonPath string) ([]byte, error) {
+	data, err := os.ReadFile(jsonPath)
+	if err == nil {
+		return data, nil
+	}
+	if len(defaultScenarios) == 0 {
+		return nil, fmt.Errorf("failed to read %s scenarios from %s: %w", environment, jsonPath, err)
+	}
+	log.Printf("Warning: %v; using the embedded default %s scenarios", err, environment)
+	return defaultScenarios, nil
+}
+
 func scenarioToFilename(scenario string) string {
 	// Manual mapping for known scenarios to ensure exact filename matches
 	mappings := map[string]string{
@@ -219,9 +286,22 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleScenarios(w http.ResponseWriter, r *http.Request) {
+	etag := responseETag(scenariosHash, r)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	sorted, err := sortScenarios(filterByTag(scenarios, r.URL.Query().Get("tag")), r.URL.Query().Get("sort"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	response := ScenarioResponse{
 		Environment:        environment,
-		Scenarios:          scenarios,
+		Scenarios:          sorted,
 		DiscardedScenarios: discardedScenarios,
 	}
 
@@ -233,6 +313,29 @@ func handleScenarios(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleRelated returns the full scenario objects a scenario links to, for
+// cascading failures such as node failure leading to quorum loss
+func handleRelated(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("scenario")
+	if name == "" {
+		http.Error(w, "Missing scenario parameter", http.StatusBadRequest)
+		return
+	}
+
+	related, ok := resolveRelated(scenarios, name)
+	if !ok {
+		http.Error(w, "Scenario not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RelatedResponse{Environment: environment, Scenario: name, Related: related}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
 // handleRecoveryProcess serves markdown recovery process documentation
 func handleRecoveryProcess(w http.ResponseWriter, r *http.Request) {
 	filename := r.URL.Query().Get("file")
@@ -242,27 +345,28 @@ func handleRecoveryProcess(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Security: prevent directory traversal attacks
-	if strings.Contains(filename, "..") || strings.Contains(filename, "/") {
-		http.Error(w, "Invalid filename", http.StatusBadRequest)
-		return
-	}
-
 	// Construct path based on mode
-	var mdPath string
+	var processDir string
 	if dataDir != "" {
 		// Container mode: recovery processes at DATA_DIR/recovery_processes/
-		mdPath = filepath.Join(dataDir, "recovery_processes", filename)
+		processDir = filepath.Join(dataDir, "recovery_processes")
 	} else {
 		// Local mode: relative to base directory
-		mdPath = filepath.Join(baseDir, "recovery_processes", environment, filename)
+		processDir = filepath.Join(baseDir, "recovery_processes", environment)
+	}
+
+	// Security: prevent directory traversal attacks
+	mdPath, err := resolveContainedPath(processDir, filename)
+	if err != nil {
+		log.Printf("Rejected recovery process filename '%s': %v", filename, err)
+		http.Error(w, "Invalid filename", http.StatusBadRequest)
+		return
 	}
-	absPath, _ := filepath.Abs(mdPath)
-	log.Printf("Loading recovery process: %s (absolute: %s)", filename, absPath)
+	log.Printf("Loading recovery process: %s (absolute: %s)", filename, mdPath)
 
 	content, err := os.ReadFile(mdPath)
 	if err != nil {
-		log.Printf("Error reading recovery process file '%s' from %s: %v", filename, absPath, err)
+		log.Printf("Error reading recovery process file '%s' from %s: %v", filename, mdPath, err)
 		http.Error(w, fmt.Sprintf("Recovery process not found: %s", filename), http.StatusNotFound)
 		return
 	}
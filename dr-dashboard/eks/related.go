@@ -0,0 +1,43 @@
+package main
+
+// Shared with eks/ and on-prem/, which carry byte-identical copies; see
+// schema.go.
+
+import "log"
+
+// checkRelated logs a warning for every related name that doesn't match a
+// scenario, so a renamed scenario doesn't silently drop out of "see also"
+func checkRelated(env string, list []DisasterScenario) {
+	names := make(map[string]bool, len(list))
+	for _, s := range list {
+		names[s.Scenario] = true
+	}
+	for _, s := range list {
+		for _, rel := range s.Related {
+			if !names[rel] {
+				log.Printf("Warning: %s scenario %q lists unknown related scenario %q", env, s.Scenario, rel)
+			}
+		}
+	}
+}
+
+// resolveRelated returns the scenario objects named in name's related list,
+// in list order, skipping dangling references. ok is false when name itself
+// isn't a scenario.
+func resolveRelated(list []DisasterScenario, name string) (related []DisasterScenario, ok bool) {
+	byName := make(map[string]DisasterScenario, len(list))
+	for _, s := range list {
+		byName[s.Scenario] = s
+	}
+	scenario, ok := byName[name]
+	if !ok {
+		return nil, false
+	}
+	related = []DisasterScenario{}
+	for _, rel := range scenario.Related {
+		if s, found := byName[rel]; found {
+			related = append(related, s)
+		}
+	}
+	return related, true
+}
@@ -29,7 +29,7 @@ echo "Downloading dependencies..."
 go mod download
 
 echo "Building application..."
-go build -o dr-dashboard-eks-bin main.go
+go build -o dr-dashboard-eks-bin .
 
 echo "Build complete!"
 echo "Starting server on port $PORT..."
@@ -0,0 +1,64 @@
+package main
+
+// Shared with eks/ and on-prem/, which carry byte-identical copies; see
+// schema.go.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TestResult is the latest automated test outcome for a scenario
+// Data source: test_results.json next to disaster_scenarios.json, keyed by scenario name
+type TestResult struct {
+	LastRun         *time.Time `json:"lastRun"`
+	LastResult      *string    `json:"lastResult"`
+	DurationSeconds *float64   `json:"durationSeconds"`
+}
+
+// loadTestResults reads test_results.json from dir. The file is optional and
+// supplied by whatever runs the scenario tests, so a missing file just means
+// nothing has reported yet.
+func loadTestResults(dir string) (map[string]TestResult, error) {
+	path := filepath.Join(dir, "test_results.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var results map[string]TestResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return results, nil
+}
+
+// mergeTestResults copies each scenario's test result onto it. Scenarios
+// without a result keep nil fields; results for unknown scenarios are logged.
+func mergeTestResults(list []DisasterScenario, results map[string]TestResult) {
+	matched := make(map[string]bool, len(results))
+	for i := range list {
+		result, ok := results[list[i].Scenario]
+		if !ok {
+			continue
+		}
+		list[i].LastRun = result.LastRun
+		list[i].LastResult = result.LastResult
+		list[i].DurationSeconds = result.DurationSeconds
+		matched[list[i].Scenario] = true
+	}
+
+	for name := range results {
+		if !matched[name] {
+			log.Printf("Warning: test result for unknown scenario %q ignored", name)
+		}
+	}
+}
@@ -1,35 +1,49 @@
 package main
 
 import (
+	"embed"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
+// buildinfo.go is a copy of the shared one; see ../connpool-monitor/buildinfo.go
+//go:generate cp ../connpool-monitor/buildinfo.go .
+
 // DisasterScenario represents a single disaster recovery scenario
 // Data source: ../testing/{eks,on-prem}/disaster_scenarios/disaster_scenarios.json
 // This maintains single source of truth with the testing framework
 type DisasterScenario struct {
-	Scenario              string  `json:"scenario"`
-	PrimaryRecoveryMethod string  `json:"primary_recovery_method"`
-	AlternateFallback     string  `json:"alternate_fallback"`
-	DetectionSignals      string  `json:"detection_signals"`
-	RTOTarget             string  `json:"rto_target"`
-	RPOTarget             string  `json:"rpo_target"`
-	MTTRExpected          string  `json:"mttr_expected"`
-	ExpectedDataLoss      string  `json:"expected_data_loss"`
-	Likelihood            string  `json:"likelihood"`
-	BusinessImpact        string  `json:"business_impact"`
-	AffectedComponents    string  `json:"affected_components"`
-	NotesAssumptions      string  `json:"notes_assumptions"`
-	TestEnabled           bool    `json:"test_enabled"`
-	TestDescription       string  `json:"test_description"`
-	TestFile              *string `json:"test_file"`
-	RecoveryProcessFile   string  `json:"recovery_process_file,omitempty"`
+	Scenario              string   `json:"scenario"`
+	PrimaryRecoveryMethod string   `json:"primary_recovery_method"`
+	AlternateFallback     string   `json:"alternate_fallback"`
+	DetectionSignals      string   `json:"detection_signals"`
+	RTOTarget             string   `json:"rto_target"`
+	RPOTarget             string   `json:"rpo_target"`
+	MTTRExpected          string   `json:"mttr_expected"`
+	ExpectedDataLoss      string   `json:"expected_data_loss"`
+	Likelihood            string   `json:"likelihood"`
+	BusinessImpact        string   `json:"business_impact"`
+	AffectedComponents    string   `json:"affected_components"`
+	Tags                  []string `json:"tags,omitempty"`
+	Related               []string `json:"related,omitempty"`
+	NotesAssumptions      string   `json:"notes_assumptions"`
+	TestEnabled           bool     `json:"test_enabled"`
+	TestDescription       string   `json:"test_description"`
+	TestFile              *string  `json:"test_file"`
+	RecoveryProcessFile   string   `json:"recovery_process_file,omitempty"`
+
+	// Merged from test_results.json; nil until the scenario's test has run
+	LastRun         *time.Time `json:"last_run,omitempty"`
+	LastResult      *string    `json:"last_result,omitempty"`
+	DurationSeconds *float64   `json:"duration_seconds,omitempty"`
 }
 
 type ScenarioResponse struct {
@@ -37,10 +51,71 @@ type ScenarioResponse struct {
 	Scenarios   []DisasterScenario `json:"scenarios"`
 }
 
-var scenarios map[string][]DisasterScenario
+// RelatedResponse is the "see also" list for one scenario
+type RelatedResponse struct {
+	Environment string             `json:"environment"`
+	Scenario    string             `json:"scenario"`
+	Related     []DisasterScenario `json:"related"`
+}
+
+// AllScenariosResponse groups the scenarios of every loaded environment
+type AllScenariosResponse struct {
+	Environments map[string][]DisasterScenario `json:"environments"`
+	Counts       map[string]int                `json:"counts"`
+	Total        int                           `json:"total"`
+}
+
+// CoverageResponse reports how many scenarios have automated tests
+type CoverageResponse struct {
+	Environment string              `json:"environment"`
+	Total       int                 `json:"total"`
+	Tested      int                 `json:"tested"`
+	Percentage  float64             `json:"percentage"`
+	Untested    map[string][]string `json:"untested_by_impact"`
+}
+
+// ReadinessFactor is one weighted input to the DR readiness score
+type ReadinessFactor struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"` // percent of the score, after dropping factors without data
+	Value  float64 `json:"value"`  // 0-100
+	Points float64 `json:"points"` // contribution to the score: weight * value / 100
+	Detail string  `json:"detail"`
+}
 
-func init() {
-	scenarios = make(map[string][]DisasterScenario)
+// ReadinessResponse is a 0-100 DR readiness score with its breakdown
+type ReadinessResponse struct {
+	Environment string            `json:"environment"`
+	Score       int               `json:"score"`
+	Weighting   string            `json:"weighting"`
+	Factors     []ReadinessFactor `json:"factors"`
+}
+
+// scenarios is replaced wholesale by loadScenarios and never mutated in
+// place, so readers may keep using a map returned by currentScenarios after
+// the lock is released.
+var (
+	scenariosMu sync.RWMutex
+	scenarios   = make(map[string][]DisasterScenario)
+
+	// Content hash per environment, replaced together with scenarios
+	scenarioHashes = make(map[string]string)
+)
+
+// envSnapshot returns one environment's scenarios and their content hash
+// from the same load
+func envSnapshot(env string) ([]DisasterScenario, string, bool) {
+	scenariosMu.RLock()
+	defer scenariosMu.RUnlock()
+	list, ok := scenarios[env]
+	return list, scenarioHashes[env], ok
+}
+
+// currentScenarios returns the scenario snapshot loaded most recently
+func currentScenarios() map[string][]DisasterScenario {
+	scenariosMu.RLock()
+	defer scenariosMu.RUnlock()
+	return scenarios
 }
 
 func main() {
@@ -51,8 +126,14 @@ func main() {
 
 	// Setup HTTP handlers
 	http.HandleFunc("/", handleIndex)
-	http.HandleFunc("/api/scenarios", handleScenarios)
+	http.HandleFunc("/version", handleVersion)
+	http.HandleFunc("/api/scenarios", withGzip(handleScenarios))
+	http.HandleFunc("/api/scenarios/all", withGzip(handleAllScenarios))
+	http.HandleFunc("/api/scenarios/coverage", handleCoverage)
+	http.HandleFunc("/api/readiness-score", handleReadinessScore)
+	http.HandleFunc("/api/scenario/related", handleRelated)
 	http.HandleFunc("/api/recovery-process", handleRecoveryProcess)
+	http.HandleFunc("/api/test-file", handleTestFile)
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("./static"))))
 
 	port := os.Getenv("PORT")
@@ -75,13 +156,19 @@ type ScenariosWrapper struct {
 // Recovery process filenames are now stored directly in the JSON files
 func loadScenarios() error {
 	environments := []string{"eks", "on-prem"}
+	loaded := make(map[string][]DisasterScenario, len(environments))
+	hashes := make(map[string]string, len(environments))
 
 	for _, env := range environments {
 		jsonPath := filepath.Join("..", "testing", env, "disaster_scenarios", "disaster_scenarios.json")
 
-		data, err := os.ReadFile(jsonPath)
+		data, err := readScenariosFile(env, jsonPath)
 		if err != nil {
-			return fmt.Errorf("failed to read %s scenarios: %w", env, err)
+			return err
+		}
+
+		if err := validateScenariosJSON(data); err != nil {
+			return fmt.Errorf("%s scenarios in %s failed schema validation:\n%w", env, jsonPath, err)
 		}
 
 		var wrapper ScenariosWrapper
@@ -89,13 +176,59 @@ func loadScenarios() error {
 			return fmt.Errorf("failed to parse %s scenarios: %w", env, err)
 		}
 
-		scenarios[env] = wrapper.Scenarios
+		results, err := loadTestResults(filepath.Dir(jsonPath))
+		if err != nil {
+			// Test results are informational; never block the dashboard on them
+			log.Printf("Warning: ignoring %s test results: %v", env, err)
+		}
+		mergeTestResults(wrapper.Scenarios, results)
+		applyTags(wrapper.Scenarios)
+		checkRelated(env, wrapper.Scenarios)
+
+		hash, err := hashScenarios(wrapper.Scenarios)
+		if err != nil {
+			return fmt.Errorf("failed to hash %s scenarios: %w", env, err)
+		}
+
+		loaded[env] = wrapper.Scenarios
+		hashes[env] = hash
 		log.Printf("Loaded %d scenarios for %s", len(wrapper.Scenarios), env)
 	}
 
+	scenariosMu.Lock()
+	scenarios = loaded
+	scenarioHashes = hashes
+	scenariosMu.Unlock()
+
 	return nil
 }
 
+// defaultScenarios is a baseline copy of each environment's scenarios so the
+// binary still starts outside the repo layout. The copies are generated from
+// the testing framework's catalogs and a test fails when they drift.
+//
+//go:generate cp ../testing/eks/disaster_scenarios/disaster_scenarios.json default_scenarios/eks.json
+//go:generate cp ../testing/on-prem/disaster_scenarios/disaster_scenarios.json default_scenarios/on-prem.json
+//go:embed default_scenarios/*.json
+var defaultScenarios embed.FS
+
+// readScenariosFile reads jsonPath, falling back to the embedded default for
+// env when the external file can't be read. The external file always wins
+// when present.
+func readScenariosFile(env, jsonPath string) ([]byte, error) {
+	data, err := os.ReadFile(jsonPath)
+	if err == nil {
+		return data, nil
+	}
+
+	embedded, embedErr := defaultScenarios.ReadFile("default_scenarios/" + env + ".json")
+	if embedErr != nil {
+		return nil, fmt.Errorf("failed to read %s scenarios: %w", env, err)
+	}
+	log.Printf("Warning: %v; using the embedded default %s scenarios", err, env)
+	return embedded, nil
+}
+
 func handleIndex(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
 		http.NotFound(w, r)
@@ -110,15 +243,28 @@ func handleScenarios(w http.ResponseWriter, r *http.Request) {
 		env = "eks"
 	}
 
-	envScenarios, ok := scenarios[env]
+	envScenarios, hash, ok := envSnapshot(env)
 	if !ok {
 		http.Error(w, "Environment not found", http.StatusNotFound)
 		return
 	}
 
+	etag := responseETag(hash, r)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	sorted, err := sortScenarios(filterByTag(envScenarios, r.URL.Query().Get("tag")), r.URL.Query().Get("sort"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	response := ScenarioResponse{
 		Environment: env,
-		Scenarios:   envScenarios,
+		Scenarios:   sorted,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -129,6 +275,224 @@ func handleScenarios(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleAllScenarios returns the scenarios of every environment in one response
+func handleAllScenarios(w http.ResponseWriter, r *http.Request) {
+	all := currentScenarios()
+	response := AllScenariosResponse{
+		Environments: make(map[string][]DisasterScenario, len(all)),
+		Counts:       make(map[string]int, len(all)),
+	}
+
+	for env, envScenarios := range all {
+		// Encode environments without scenarios as [] rather than null
+		if envScenarios == nil {
+			envScenarios = []DisasterScenario{}
+		}
+		response.Environments[env] = envScenarios
+		response.Counts[env] = len(envScenarios)
+		response.Total += len(envScenarios)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleCoverage reports the share of scenarios covered by an automated test
+func handleCoverage(w http.ResponseWriter, r *http.Request) {
+	env := r.URL.Query().Get("env")
+	if env == "" {
+		env = "eks"
+	}
+
+	envScenarios, ok := currentScenarios()[env]
+	if !ok {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+
+	response := computeCoverage(env, envScenarios)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleRelated returns the full scenario objects a scenario links to, for
+// cascading failures such as node failure leading to quorum loss
+func handleRelated(w http.ResponseWriter, r *http.Request) {
+	env := r.URL.Query().Get("env")
+	name := r.URL.Query().Get("scenario")
+
+	if env == "" || name == "" {
+		http.Error(w, "Missing env or scenario parameter", http.StatusBadRequest)
+		return
+	}
+
+	envScenarios, ok := currentScenarios()[env]
+	if !ok {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+
+	related, ok := resolveRelated(envScenarios, name)
+	if !ok {
+		http.Error(w, "Scenario not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RelatedResponse{Environment: env, Scenario: name, Related: related}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// computeCoverage counts a scenario as tested only when its test is enabled
+// and points at a test file; the rest are grouped by business impact
+func computeCoverage(env string, envScenarios []DisasterScenario) CoverageResponse {
+	response := CoverageResponse{
+		Environment: env,
+		Total:       len(envScenarios),
+		Untested:    make(map[string][]string),
+	}
+
+	for _, s := range envScenarios {
+		if s.TestEnabled && s.TestFile != nil && *s.TestFile != "" {
+			response.Tested++
+			continue
+		}
+		impact := strings.ToLower(s.BusinessImpact)
+		if impact == "" {
+			impact = "unknown"
+		}
+		response.Untested[impact] = append(response.Untested[impact], s.Scenario)
+	}
+
+	response.Percentage = percentOf(response.Tested, response.Total)
+	return response
+}
+
+// Readiness score weights. A test pass rate is only available once the test
+// runner has reported recent results; without one the other weights are
+// scaled up to fill the score.
+const (
+	readinessCoverageWeight = 40
+	readinessProcessWeight  = 40
+	readinessPassRateWeight = 20
+
+	// Test results older than this don't count towards the pass rate
+	readinessResultMaxAge = 30 * 24 * time.Hour
+)
+
+const readinessWeighting = "test coverage 40%, recovery process documents 40%, test pass rate 20% (results from the last 30 days); factors without data are dropped and the remaining weights scaled to 100%"
+
+func handleReadinessScore(w http.ResponseWriter, r *http.Request) {
+	env := r.URL.Query().Get("env")
+	if env == "" {
+		env = "eks"
+	}
+
+	envScenarios, ok := currentScenarios()[env]
+	if !ok {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+
+	response := computeReadiness(env, envScenarios, filepath.Join("recovery_processes", env), time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// computeReadiness combines test coverage, recovery process documents found
+// in processDir and the recent test pass rate into one weighted score
+func computeReadiness(env string, envScenarios []DisasterScenario, processDir string, now time.Time) ReadinessResponse {
+	coverage := computeCoverage(env, envScenarios)
+	factors := []ReadinessFactor{{
+		Name:   "test_coverage",
+		Weight: readinessCoverageWeight,
+		Value:  coverage.Percentage,
+		Detail: fmt.Sprintf("%d of %d scenarios have an automated test", coverage.Tested, coverage.Total),
+	}}
+
+	documented := 0
+	for _, s := range envScenarios {
+		if s.RecoveryProcessFile == "" {
+			continue
+		}
+		path, err := resolveContainedPath(processDir, s.RecoveryProcessFile)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			documented++
+		}
+	}
+	factors = append(factors, ReadinessFactor{
+		Name:   "recovery_processes",
+		Weight: readinessProcessWeight,
+		Value:  percentOf(documented, len(envScenarios)),
+		Detail: fmt.Sprintf("%d of %d scenarios have a recovery process document", documented, len(envScenarios)),
+	})
+
+	recent, passed := 0, 0
+	for _, s := range envScenarios {
+		if s.LastRun == nil || s.LastResult == nil || now.Sub(*s.LastRun) > readinessResultMaxAge {
+			continue
+		}
+		recent++
+		if strings.EqualFold(*s.LastResult, "passed") {
+			passed++
+		}
+	}
+	if recent > 0 {
+		factors = append(factors, ReadinessFactor{
+			Name:   "test_pass_rate",
+			Weight: readinessPassRateWeight,
+			Value:  percentOf(passed, recent),
+			Detail: fmt.Sprintf("%d of %d recent test runs passed", passed, recent),
+		})
+	}
+
+	var totalWeight, score float64
+	for _, f := range factors {
+		totalWeight += f.Weight
+	}
+	for i := range factors {
+		factors[i].Weight = math.Round(factors[i].Weight/totalWeight*1000) / 10
+		points := factors[i].Weight * factors[i].Value / 100
+		factors[i].Points = math.Round(points*10) / 10
+		score += points
+	}
+
+	return ReadinessResponse{
+		Environment: env,
+		Score:       int(math.Round(score)),
+		Weighting:   readinessWeighting,
+		Factors:     factors,
+	}
+}
+
+// percentOf returns n/total as a percentage rounded to one decimal
+func percentOf(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(total)*1000) / 10
+}
+
 // handleRecoveryProcess serves markdown recovery process documentation
 func handleRecoveryProcess(w http.ResponseWriter, r *http.Request) {
 	env := r.URL.Query().Get("env")
@@ -139,13 +503,20 @@ func handleRecoveryProcess(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only serve from directories of environments we actually loaded
+	if _, ok := currentScenarios()[env]; !ok {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+
 	// Security: prevent directory traversal attacks
-	if strings.Contains(filename, "..") || strings.Contains(filename, "/") {
+	mdPath, err := resolveContainedPath(filepath.Join("recovery_processes", env), filename)
+	if err != nil {
+		log.Printf("Rejected recovery process filename '%s': %v", filename, err)
 		http.Error(w, "Invalid filename", http.StatusBadRequest)
 		return
 	}
 
-	mdPath := filepath.Join("recovery_processes", env, filename)
 	content, err := os.ReadFile(mdPath)
 	if err != nil {
 		http.Error(w, "Recovery process not found", http.StatusNotFound)
@@ -157,3 +528,58 @@ func handleRecoveryProcess(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Error writing response: %v", err)
 	}
 }
+
+// handleTestFile serves the automated test referenced by a scenario's
+// test_file so it can be read next to the runbook
+func handleTestFile(w http.ResponseWriter, r *http.Request) {
+	env := r.URL.Query().Get("env")
+	name := r.URL.Query().Get("scenario")
+
+	if env == "" || name == "" {
+		http.Error(w, "Missing env or scenario parameter", http.StatusBadRequest)
+		return
+	}
+
+	envScenarios, ok := currentScenarios()[env]
+	if !ok {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+
+	var scenario *DisasterScenario
+	for i := range envScenarios {
+		if envScenarios[i].Scenario == name {
+			scenario = &envScenarios[i]
+			break
+		}
+	}
+	if scenario == nil {
+		http.Error(w, "Scenario not found", http.StatusNotFound)
+		return
+	}
+	if scenario.TestFile == nil || *scenario.TestFile == "" {
+		http.Error(w, fmt.Sprintf("Scenario %q has no automated test", name), http.StatusNotFound)
+		return
+	}
+
+	// Security: test_file comes from the catalog, but apply the same
+	// traversal guards as recovery processes
+	testDir := filepath.Join("..", "testing", env, "resiliency")
+	testPath, err := resolveContainedPath(testDir, *scenario.TestFile)
+	if err != nil {
+		log.Printf("Rejected test file '%s' for scenario '%s': %v", *scenario.TestFile, name, err)
+		http.Error(w, "Invalid test file", http.StatusBadRequest)
+		return
+	}
+
+	content, err := os.ReadFile(testPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Test file not found: %s", *scenario.TestFile), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write(content); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
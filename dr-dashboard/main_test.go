@@ -0,0 +1,865 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResolveContainedPath(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ok.md"), []byte("# ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.md"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.md"), filepath.Join(dir, "link.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := resolveContainedPath(dir, "ok.md")
+	if err != nil {
+		t.Fatalf("ok.md: unexpected error: %v", err)
+	}
+	if filepath.Base(got) != "ok.md" {
+		t.Errorf("ok.md resolved to %s", got)
+	}
+
+	for _, name := range []string{
+		"../secret.md",
+		"..%2fsecret.md",
+		"..%2Fsecret.md",
+		`..\secret.md`,
+		`sub\..\..\secret.md`,
+		filepath.Join(outside, "secret.md"),
+		"link.md",
+	} {
+		if p, err := resolveContainedPath(dir, name); err == nil {
+			t.Errorf("%q: expected rejection, resolved to %s", name, p)
+		}
+	}
+}
+
+// setScenarios installs list as the loaded scenarios for the duration of
+// the test
+func setScenarios(t *testing.T, loaded map[string][]DisasterScenario) {
+	t.Helper()
+	hashes := make(map[string]string, len(loaded))
+	for env, list := range loaded {
+		hash, err := hashScenarios(list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[env] = hash
+	}
+
+	scenariosMu.Lock()
+	prev, prevHashes := scenarios, scenarioHashes
+	scenarios, scenarioHashes = loaded, hashes
+	scenariosMu.Unlock()
+
+	t.Cleanup(func() {
+		scenariosMu.Lock()
+		scenarios, scenarioHashes = prev, prevHashes
+		scenariosMu.Unlock()
+	})
+}
+
+// chdir switches the working directory for the rest of the test, for
+// handlers that resolve paths relative to it
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// useCatalogs lays out ../testing/{env}/disaster_scenarios as loadScenarios
+// expects and switches into it; write replaces an environment's catalog
+func useCatalogs(t *testing.T) (write func(env string, data []byte)) {
+	t.Helper()
+	root := t.TempDir()
+	dashDir := filepath.Join(root, "dr-dashboard")
+	if err := os.MkdirAll(dashDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dashDir)
+	setScenarios(t, nil)
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	return func(env string, data []byte) {
+		t.Helper()
+		dir := filepath.Join(root, "testing", env, "disaster_scenarios")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "disaster_scenarios.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHandleAllScenarios(t *testing.T) {
+	setScenarios(t, map[string][]DisasterScenario{
+		"eks":     {{Scenario: "Pod failure"}, {Scenario: "Node failure"}},
+		"on-prem": nil,
+	})
+
+	rec := httptest.NewRecorder()
+	handleAllScenarios(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios/all", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+
+	var raw struct {
+		Environments map[string]json.RawMessage `json:"environments"`
+		Counts       map[string]int             `json:"counts"`
+		Total        int                        `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.Counts["eks"] != 2 || raw.Total != 2 {
+		t.Errorf("counts = %v, total = %d", raw.Counts, raw.Total)
+	}
+	if count, ok := raw.Counts["on-prem"]; !ok || count != 0 {
+		t.Errorf("on-prem count = %d, present %v", count, ok)
+	}
+	if got := string(raw.Environments["on-prem"]); got != "[]" {
+		t.Errorf("on-prem scenarios = %s, want []", got)
+	}
+}
+
+func TestWithGzip(t *testing.T) {
+	large := make([]DisasterScenario, 50)
+	for i := range large {
+		large[i] = DisasterScenario{Scenario: "Scenario " + strconv.Itoa(i), PrimaryRecoveryMethod: strings.Repeat("Restore from backup. ", 5)}
+	}
+	setScenarios(t, map[string][]DisasterScenario{
+		"eks":     large,
+		"on-prem": {{Scenario: "Pod failure"}},
+	})
+	handler := withGzip(handleScenarios)
+
+	get := func(env, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/scenarios?env="+env, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d", rec.Code)
+		}
+		return rec
+	}
+
+	plain := get("eks", "")
+	if plain.Header().Get("Content-Encoding") != "" {
+		t.Errorf("compressed without Accept-Encoding")
+	}
+	if plain.Body.Len() < gzipMinSize {
+		t.Fatalf("test response is %d bytes, below the %d threshold", plain.Body.Len(), gzipMinSize)
+	}
+
+	zipped := get("eks", "deflate, gzip")
+	if zipped.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", zipped.Header().Get("Content-Encoding"))
+	}
+	if !strings.Contains(zipped.Header().Get("Vary"), "Accept-Encoding") {
+		t.Errorf("Vary = %q, want Accept-Encoding", zipped.Header().Get("Vary"))
+	}
+	zr, err := gzip.NewReader(zipped.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, plain.Body.Bytes()) {
+		t.Error("decompressed body differs from the plain response")
+	}
+
+	if enc := get("eks", "gzip;q=0").Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q for gzip;q=0, want none", enc)
+	}
+	if enc := get("on-prem", "gzip").Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q for a small response, want none", enc)
+	}
+}
+
+func TestHandleCoverage(t *testing.T) {
+	file := func(s string) *string { return &s }
+	setScenarios(t, map[string][]DisasterScenario{
+		"eks": {
+			{Scenario: "Pod failure", BusinessImpact: "High", TestEnabled: true, TestFile: file("test_pod_failure.py")},
+			{Scenario: "Node failure", BusinessImpact: "High", TestEnabled: true, TestFile: file("test_node_failure.py")},
+			{Scenario: "Quorum loss", BusinessImpact: "Critical", TestEnabled: true},
+			{Scenario: "Bad DDL", BusinessImpact: "High", TestFile: file("test_ddl.py")},
+			{Scenario: "Region outage", BusinessImpact: "Critical", TestEnabled: true, TestFile: file("")},
+			{Scenario: "Mystery"},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	handleCoverage(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios/coverage?env=eks", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	var got CoverageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Total != 6 || got.Tested != 2 || got.Percentage != 33.3 {
+		t.Errorf("total/tested/percentage = %d/%d/%v, want 6/2/33.3", got.Total, got.Tested, got.Percentage)
+	}
+	want := map[string][]string{
+		"critical": {"Quorum loss", "Region outage"},
+		"high":     {"Bad DDL"},
+		"unknown":  {"Mystery"},
+	}
+	if !reflect.DeepEqual(got.Untested, want) {
+		t.Errorf("untested = %v, want %v", got.Untested, want)
+	}
+
+	rec = httptest.NewRecorder()
+	handleCoverage(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios/coverage?env=gke", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown env: status %d, want 404", rec.Code)
+	}
+}
+
+func TestHandleTestFile(t *testing.T) {
+	// The handler reads from ../testing/{env}/resiliency relative to the
+	// working directory, so lay that out in a temp dir
+	root := t.TempDir()
+	dashDir := filepath.Join(root, "dr-dashboard")
+	testDir := filepath.Join(root, "testing", "eks", "resiliency")
+	for _, dir := range []string{dashDir, testDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(testDir, "test_pod_failure.py"), []byte("def test_pod_failure():\n    pass\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "testing", "eks", "secret.py"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dashDir)
+
+	file := func(s string) *string { return &s }
+	setScenarios(t, map[string][]DisasterScenario{
+		"eks": {
+			{Scenario: "Pod failure", TestFile: file("test_pod_failure.py")},
+			{Scenario: "Traversal", TestFile: file("../secret.py")},
+			{Scenario: "Encoded traversal", TestFile: file("..%2fsecret.py")},
+			{Scenario: "Untested"},
+			{Scenario: "Missing", TestFile: file("test_missing.py")},
+		},
+	})
+
+	get := func(scenario string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/test-file?env=eks&scenario="+url.QueryEscape(scenario), nil)
+		handleTestFile(rec, req)
+		return rec
+	}
+
+	rec := get("Pod failure")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "def test_pod_failure") {
+		t.Errorf("fixture: status %d, body %q", rec.Code, rec.Body.String())
+	}
+	for _, name := range []string{"Traversal", "Encoded traversal"} {
+		rec = get(name)
+		if rec.Code != http.StatusBadRequest || strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("%s: status %d, body %q, want 400", name, rec.Code, rec.Body.String())
+		}
+	}
+	rec = get("Untested")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "has no automated test") {
+		t.Errorf("nil test_file: status %d, body %q", rec.Code, rec.Body.String())
+	}
+	if rec = get("Missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing file: status %d, want 404", rec.Code)
+	}
+}
+
+func TestSortScenarios(t *testing.T) {
+	list := []DisasterScenario{
+		{Scenario: "delta", BusinessImpact: "Low", Likelihood: "High", RTOTarget: "2 hours"},
+		{Scenario: "Alpha", BusinessImpact: "Critical", Likelihood: "Low", RTOTarget: "N/A (manual)"},
+		{Scenario: "charlie", BusinessImpact: "High", Likelihood: "Medium", RTOTarget: "90 seconds"},
+		{Scenario: "bravo", BusinessImpact: "Critical", Likelihood: "unknown", RTOTarget: "15 minutes"},
+		{Scenario: "echo", BusinessImpact: "Medium", Likelihood: "Critical", RTOTarget: "1 day"},
+		{Scenario: "foxtrot", BusinessImpact: "Medium", Likelihood: "High", RTOTarget: "30"},
+	}
+
+	for _, tc := range []struct {
+		mode string
+		want []string
+	}{
+		{"", []string{"Alpha", "bravo", "charlie", "delta", "echo", "foxtrot"}},
+		{"name", []string{"Alpha", "bravo", "charlie", "delta", "echo", "foxtrot"}},
+		{"impact", []string{"Alpha", "bravo", "charlie", "echo", "foxtrot", "delta"}},
+		{"likelihood", []string{"echo", "delta", "foxtrot", "charlie", "Alpha", "bravo"}},
+		// 90s < 15m < 30 (bare minutes) < 2h < 1d < unparseable
+		{"rto", []string{"charlie", "bravo", "foxtrot", "delta", "echo", "Alpha"}},
+	} {
+		sorted, err := sortScenarios(list, tc.mode)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.mode, err)
+		}
+		var got []string
+		for _, s := range sorted {
+			got = append(got, s.Scenario)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("sort=%q: got %v, want %v", tc.mode, got, tc.want)
+		}
+	}
+	if list[0].Scenario != "delta" {
+		t.Error("sortScenarios reordered its input")
+	}
+	if _, err := sortScenarios(list, "color"); err == nil {
+		t.Error("unknown sort mode accepted")
+	}
+}
+
+func TestParseRTO(t *testing.T) {
+	for rto, want := range map[string]time.Duration{
+		"30 seconds":        30 * time.Second,
+		"15 minutes":        15 * time.Minute,
+		"1.5 hours":         90 * time.Minute,
+		"2 days":            48 * time.Hour,
+		"45":                45 * time.Minute,
+		"  5 Minutes (ish)": 5 * time.Minute,
+	} {
+		if got := parseRTO(rto); got != want {
+			t.Errorf("parseRTO(%q) = %s, want %s", rto, got, want)
+		}
+	}
+	if got := parseRTO("N/A"); got != time.Duration(math.MaxInt64) {
+		t.Errorf("parseRTO(N/A) = %s, want it to sort last", got)
+	}
+}
+
+func TestHandleScenariosSortParam(t *testing.T) {
+	setScenarios(t, map[string][]DisasterScenario{
+		"eks": {{Scenario: "b", RTOTarget: "1 hour"}, {Scenario: "a", RTOTarget: "5 minutes"}, {Scenario: "c", RTOTarget: "1 minute"}},
+	})
+
+	rec := httptest.NewRecorder()
+	handleScenarios(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios?env=eks&sort=rto", nil))
+	var got ScenarioResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Scenarios) != 3 || got.Scenarios[0].Scenario != "c" || got.Scenarios[2].Scenario != "b" {
+		t.Errorf("sort=rto returned %+v", got.Scenarios)
+	}
+
+	rec = httptest.NewRecorder()
+	handleScenarios(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios?env=eks&sort=color", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown sort: status %d, want 400", rec.Code)
+	}
+}
+
+func TestConcurrentReloadAndRead(t *testing.T) {
+	// Lay out ../testing/{env}/disaster_scenarios as loadScenarios expects,
+	// then keep swapping the catalogs between a one- and two-scenario version
+	root := t.TempDir()
+	dashDir := filepath.Join(root, "dr-dashboard")
+	if err := os.MkdirAll(dashDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	catalogs := [][]byte{
+		catalogJSON(t, map[string]interface{}{"scenarios": []interface{}{validScenario("Pod crash")}}),
+		catalogJSON(t, map[string]interface{}{"scenarios": []interface{}{validScenario("Pod crash"), validScenario("Node loss")}}),
+	}
+	writeCatalogs := func(data []byte) {
+		for _, env := range []string{"eks", "on-prem"} {
+			dir := filepath.Join(root, "testing", env, "disaster_scenarios")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Error(err)
+				return
+			}
+			// Rename so a reload never reads a half-written file
+			tmp := filepath.Join(dir, "disaster_scenarios.json.tmp")
+			if err := os.WriteFile(tmp, data, 0644); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := os.Rename(tmp, filepath.Join(dir, "disaster_scenarios.json")); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	writeCatalogs(catalogs[0])
+	chdir(t, dashDir)
+	setScenarios(t, nil)
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	reloaded := make(chan int)
+	go func() {
+		i := 0
+		defer func() { reloaded <- i }()
+		for ; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			writeCatalogs(catalogs[i%2])
+			if err := loadScenarios(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for i := 0; i < 200; i++ {
+				// The list and its hash must come from the same load
+				list, hash, ok := envSnapshot("eks")
+				if !ok {
+					t.Error("eks missing after reload")
+					return
+				}
+				want, err := hashScenarios(list)
+				if err != nil || hash != want {
+					t.Errorf("hash %s does not match the %d scenarios returned with it", hash, len(list))
+					return
+				}
+
+				rec := httptest.NewRecorder()
+				handleAllScenarios(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios/all", nil))
+				var all AllScenariosResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil {
+					t.Error(err)
+					return
+				}
+				if n := all.Counts["eks"]; n != len(all.Environments["eks"]) || (n != 1 && n != 2) {
+					t.Errorf("eks count %d with %d scenarios", n, len(all.Environments["eks"]))
+					return
+				}
+			}
+		}()
+	}
+
+	readers.Wait()
+	close(stop)
+	if n := <-reloaded; n < 2 {
+		t.Errorf("only %d reloads overlapped the reads", n)
+	}
+}
+
+func TestLoadAndMergeTestResults(t *testing.T) {
+	dir := t.TempDir()
+	if results, err := loadTestResults(dir); err != nil || results != nil {
+		t.Fatalf("missing file: results %v, err %v", results, err)
+	}
+
+	data := `{
+		"Pod failure": {"lastRun": "2026-01-15T09:30:00Z", "lastResult": "passed", "durationSeconds": 184.2},
+		"Quorum loss": {"lastRun": "2026-01-10T12:00:00Z", "lastResult": "failed"},
+		"Retired scenario": {"lastRun": "2025-06-01T00:00:00Z", "lastResult": "passed"}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "test_results.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := loadTestResults(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := []DisasterScenario{{Scenario: "Pod failure"}, {Scenario: "Node failure"}, {Scenario: "Quorum loss"}}
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	mergeTestResults(list, results)
+
+	pod := list[0]
+	if pod.LastRun == nil || !pod.LastRun.Equal(time.Date(2026, 1, 15, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("pod failure last run = %v", pod.LastRun)
+	}
+	if pod.LastResult == nil || *pod.LastResult != "passed" || pod.DurationSeconds == nil || *pod.DurationSeconds != 184.2 {
+		t.Errorf("pod failure result/duration not merged: %+v", pod)
+	}
+
+	if node := list[1]; node.LastRun != nil || node.LastResult != nil || node.DurationSeconds != nil {
+		t.Errorf("scenario without a result gained fields: %+v", node)
+	}
+
+	quorum := list[2]
+	if quorum.LastResult == nil || *quorum.LastResult != "failed" || quorum.DurationSeconds != nil {
+		t.Errorf("quorum loss: result %v, duration %v", quorum.LastResult, quorum.DurationSeconds)
+	}
+
+	// Untested scenarios omit the fields entirely in the API response
+	encoded, err := json.Marshal(list[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(encoded), "last_run") {
+		t.Errorf("nil result fields encoded: %s", encoded)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "test_results.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTestResults(dir); err == nil {
+		t.Error("malformed test_results.json accepted")
+	}
+}
+
+func TestLoadScenariosTags(t *testing.T) {
+	explicit := validScenario("Pod crash")
+	explicit["tags"] = []interface{}{" Kubernetes", "PXC"}
+	derived := validScenario("Volume full")
+	delete(derived, "tags")
+	derived["affected_components"] = "PXC data files, PVC, HAProxy"
+	untagged := validScenario("Region outage")
+	delete(untagged, "tags")
+	untagged["affected_components"] = "Everything"
+
+	write := useCatalogs(t)
+	write("eks", catalogJSON(t, map[string]interface{}{"scenarios": []interface{}{explicit, derived, untagged}}))
+	write("on-prem", catalogJSON(t, map[string]interface{}{"scenarios": []interface{}{validScenario("Node loss")}}))
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, _, _ := envSnapshot("eks")
+	want := map[string][]string{
+		"Pod crash":     {"kubernetes", "pxc"},
+		"Volume full":   {"proxy", "storage"},
+		"Region outage": nil,
+	}
+	for _, s := range list {
+		if !reflect.DeepEqual(s.Tags, want[s.Scenario]) {
+			t.Errorf("%s tags = %q, want %q", s.Scenario, s.Tags, want[s.Scenario])
+		}
+	}
+}
+
+func TestHandleScenariosTagFilter(t *testing.T) {
+	setScenarios(t, map[string][]DisasterScenario{
+		"eks": {
+			{Scenario: "Volume full", Tags: []string{"storage"}},
+			{Scenario: "Pod crash", Tags: []string{"kubernetes"}},
+			{Scenario: "EBS loss", Tags: []string{"kubernetes", "storage"}},
+		},
+	})
+
+	for _, tc := range []struct {
+		tag  string
+		want []string
+	}{
+		{"storage", []string{"EBS loss", "Volume full"}},
+		{" Storage ", []string{"EBS loss", "Volume full"}},
+		{"", []string{"EBS loss", "Pod crash", "Volume full"}},
+		{"network", []string{}},
+	} {
+		rec := httptest.NewRecorder()
+		handleScenarios(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios?env=eks&sort=name&tag="+url.QueryEscape(tc.tag), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("tag %q: status %d", tc.tag, rec.Code)
+		}
+		var resp struct {
+			Scenarios []DisasterScenario `json:"scenarios"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Scenarios == nil {
+			t.Errorf("tag %q: scenarios is null, want an array", tc.tag)
+		}
+		got := []string{}
+		for _, s := range resp.Scenarios {
+			got = append(got, s.Scenario)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("tag %q: got %q, want %q", tc.tag, got, tc.want)
+		}
+	}
+}
+
+func TestScenariosETag(t *testing.T) {
+	write := useCatalogs(t)
+	write("on-prem", catalogJSON(t, map[string]interface{}{"scenarios": []interface{}{validScenario("Node loss")}}))
+	write("eks", catalogJSON(t, map[string]interface{}{"scenarios": []interface{}{validScenario("Pod crash")}}))
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+	handler := withGzip(handleScenarios)
+
+	get := func(ifNoneMatch, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/scenarios?env=eks", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	first := get("", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status %d, ETag %q", first.Code, etag)
+	}
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("ETag %q is strong, but gzip and identity bodies share it", etag)
+	}
+	if got := get("", "gzip").Header().Get("ETag"); got != etag {
+		t.Errorf("gzip ETag %q differs from identity ETag %q", got, etag)
+	}
+
+	for _, encoding := range []string{"", "gzip"} {
+		rec := get(etag, encoding)
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("Accept-Encoding %q: status %d for matching If-None-Match, want 304", encoding, rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("Accept-Encoding %q: 304 carried a %d byte body", encoding, rec.Body.Len())
+		}
+	}
+	if rec := get(`W/"stale"`, ""); rec.Code != http.StatusOK {
+		t.Errorf("status %d for a stale If-None-Match, want 200", rec.Code)
+	}
+
+	write("eks", catalogJSON(t, map[string]interface{}{"scenarios": []interface{}{validScenario("Pod crash"), validScenario("Node loss")}}))
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := get(etag, "")
+	if reloaded.Code != http.StatusOK {
+		t.Fatalf("status %d after reload, want 200 for the old ETag", reloaded.Code)
+	}
+	if reloaded.Header().Get("ETag") == etag {
+		t.Errorf("ETag %q unchanged after the catalog changed", etag)
+	}
+}
+
+func TestBuildInfoCopyInSync(t *testing.T) {
+	shared, err := os.ReadFile(filepath.Join("..", "connpool-monitor", "buildinfo.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := os.ReadFile("buildinfo.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(shared, local) {
+		t.Error("buildinfo.go differs from the connpool-monitor one; run go generate")
+	}
+}
+
+func TestHandleVersion(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleVersion(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	var info BuildInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Commit != "dev" || info.BuildTime != "dev" || info.GoVersion != runtime.Version() {
+		t.Errorf("got %+v, want dev defaults without -ldflags", info)
+	}
+}
+
+func TestHandleRelated(t *testing.T) {
+	setScenarios(t, map[string][]DisasterScenario{
+		"eks": {
+			{Scenario: "Worker node failure", Related: []string{"Quorum loss", "Deleted scenario"}},
+			{Scenario: "Quorum loss", Related: []string{"Worker node failure"}},
+			{Scenario: "Backup failure"},
+		},
+	})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handleRelated(rec, httptest.NewRequest(http.MethodGet, "/api/scenario/related?"+query, nil))
+		return rec
+	}
+
+	rec := get("env=eks&scenario=" + url.QueryEscape("Worker node failure"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	var resp RelatedResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Related) != 1 || resp.Related[0].Scenario != "Quorum loss" {
+		t.Fatalf("related = %+v, want the Quorum loss scenario only", resp.Related)
+	}
+	if !reflect.DeepEqual(resp.Related[0].Related, []string{"Worker node failure"}) {
+		t.Errorf("related entry is not the full scenario object: %+v", resp.Related[0])
+	}
+
+	if body := get("env=eks&scenario=" + url.QueryEscape("Backup failure")).Body.String(); !strings.Contains(body, `"related":[]`) {
+		t.Errorf("scenario without related links: %s, want an empty array", body)
+	}
+
+	for query, want := range map[string]int{
+		"env=eks":                  http.StatusBadRequest,
+		"env=gke&scenario=x":       http.StatusNotFound,
+		"env=eks&scenario=Missing": http.StatusNotFound,
+	} {
+		if code := get(query).Code; code != want {
+			t.Errorf("%s: status %d, want %d", query, code, want)
+		}
+	}
+}
+
+func TestCheckRelatedWarnsOnDanglingReference(t *testing.T) {
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	checkRelated("eks", []DisasterScenario{
+		{Scenario: "Worker node failure", Related: []string{"Quorum loss", "Deleted scenario"}},
+		{Scenario: "Quorum loss", Related: []string{"Worker node failure"}},
+	})
+
+	out := logs.String()
+	if !strings.Contains(out, `eks scenario "Worker node failure" lists unknown related scenario "Deleted scenario"`) {
+		t.Errorf("dangling reference not warned about:\n%s", out)
+	}
+	if strings.Count(out, "Warning") != 1 {
+		t.Errorf("want exactly one warning, got:\n%s", out)
+	}
+}
+
+func TestComputeReadiness(t *testing.T) {
+	processDir := t.TempDir()
+	for _, name := range []string{"a.md", "c.md", "d.md"} {
+		if err := os.WriteFile(filepath.Join(processDir, name), []byte("# steps"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent, old := now.Add(-24*time.Hour), now.Add(-60*24*time.Hour)
+	passed, failed := "passed", "failed"
+	testFile := "test_pod_failure.py"
+
+	list := []DisasterScenario{
+		{Scenario: "A", TestEnabled: true, TestFile: &testFile, RecoveryProcessFile: "a.md", LastRun: &recent, LastResult: &passed},
+		{Scenario: "B", TestEnabled: true, TestFile: &testFile, RecoveryProcessFile: "missing.md", LastRun: &recent, LastResult: &failed},
+		{Scenario: "C", RecoveryProcessFile: "c.md", LastRun: &old, LastResult: &failed},
+		{Scenario: "D", RecoveryProcessFile: "d.md"},
+		{Scenario: "E", RecoveryProcessFile: "../" + filepath.Base(processDir) + "/a.md"},
+	}
+
+	got := computeReadiness("eks", list[:4], processDir, now)
+	want := []ReadinessFactor{
+		{Name: "test_coverage", Weight: 40, Value: 50, Points: 20, Detail: "2 of 4 scenarios have an automated test"},
+		{Name: "recovery_processes", Weight: 40, Value: 75, Points: 30, Detail: "3 of 4 scenarios have a recovery process document"},
+		{Name: "test_pass_rate", Weight: 20, Value: 50, Points: 10, Detail: "1 of 2 recent test runs passed"},
+	}
+	if got.Score != 60 || !reflect.DeepEqual(got.Factors, want) {
+		t.Errorf("score %d, factors %+v\nwant 60, %+v", got.Score, got.Factors, want)
+	}
+	if got.Weighting == "" {
+		t.Error("response does not document the weighting")
+	}
+
+	// Without recent results the pass rate drops out and the rest is reweighted
+	got = computeReadiness("eks", []DisasterScenario{list[0], list[2], list[3], list[4]}, processDir, now.Add(90*24*time.Hour))
+	want = []ReadinessFactor{
+		{Name: "test_coverage", Weight: 50, Value: 25, Points: 12.5, Detail: "1 of 4 scenarios have an automated test"},
+		{Name: "recovery_processes", Weight: 50, Value: 75, Points: 37.5, Detail: "3 of 4 scenarios have a recovery process document"},
+	}
+	if got.Score != 50 || !reflect.DeepEqual(got.Factors, want) {
+		t.Errorf("score %d, factors %+v\nwant 50, %+v", got.Score, got.Factors, want)
+	}
+}
+
+func TestDefaultScenariosInSync(t *testing.T) {
+	for _, env := range []string{"eks", "on-prem"} {
+		catalog, err := os.ReadFile(filepath.Join("..", "testing", env, "disaster_scenarios", "disaster_scenarios.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		embedded, err := defaultScenarios.ReadFile("default_scenarios/" + env + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(catalog, embedded) {
+			t.Errorf("default_scenarios/%s.json differs from the testing catalog; run go generate", env)
+		}
+	}
+}
+
+func TestLoadScenariosEmbeddedFallback(t *testing.T) {
+	write := useCatalogs(t)
+	var logs strings.Builder
+	log.SetOutput(&logs)
+
+	// Only on-prem has an external catalog; eks falls back to the embedded one
+	write("on-prem", catalogJSON(t, map[string]interface{}{"scenarios": []interface{}{validScenario("Node loss")}}))
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+
+	var embedded ScenariosWrapper
+	data, err := defaultScenarios.ReadFile("default_scenarios/eks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &embedded); err != nil {
+		t.Fatal(err)
+	}
+	eks, _, _ := envSnapshot("eks")
+	if len(eks) == 0 || len(eks) != len(embedded.Scenarios) {
+		t.Errorf("eks loaded %d scenarios, want the %d embedded ones", len(eks), len(embedded.Scenarios))
+	}
+	if onPrem, _, _ := envSnapshot("on-prem"); len(onPrem) != 1 || onPrem[0].Scenario != "Node loss" {
+		t.Errorf("on-prem = %+v, want the external catalog", onPrem)
+	}
+	if !strings.Contains(logs.String(), "using the embedded default eks scenarios") {
+		t.Errorf("fallback not logged:\n%s", logs.String())
+	}
+}
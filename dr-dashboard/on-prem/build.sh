@@ -36,6 +36,10 @@ if [ ! -d "$DR_DASHBOARD_DIR/recovery_processes/on-prem" ]; then
     exit 1
 fi
 
+# Build metadata reported by /version
+GIT_COMMIT="$(git -C "$REPO_ROOT" rev-parse --short HEAD 2>/dev/null || echo dev)"
+BUILD_TIME="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+
 # Build image name
 if [ -n "$REGISTRY" ]; then
     FULL_IMAGE="${REGISTRY}/${IMAGE_NAME}:${TAG}"
@@ -61,6 +65,8 @@ fi
 echo "Building: $FULL_IMAGE"
 $DOCKER_CMD build \
     -f "$SCRIPT_DIR/Dockerfile" \
+    --build-arg GIT_COMMIT="$GIT_COMMIT" \
+    --build-arg BUILD_TIME="$BUILD_TIME" \
     -t "$FULL_IMAGE" \
     "$REPO_ROOT"
 
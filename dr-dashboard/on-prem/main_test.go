@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveContainedPath(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ok.md"), []byte("# ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.md"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.md"), filepath.Join(dir, "link.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := resolveContainedPath(dir, "ok.md")
+	if err != nil {
+		t.Fatalf("ok.md: unexpected error: %v", err)
+	}
+	if filepath.Base(got) != "ok.md" {
+		t.Errorf("ok.md resolved to %s", got)
+	}
+
+	for _, name := range []string{
+		"../secret.md",
+		"..%2fsecret.md",
+		"..%2Fsecret.md",
+		`..\secret.md`,
+		`sub\..\..\secret.md`,
+		filepath.Join(outside, "secret.md"),
+		"link.md",
+	} {
+		if p, err := resolveContainedPath(dir, name); err == nil {
+			t.Errorf("%q: expected rejection, resolved to %s", name, p)
+		}
+	}
+}
+
+// sharedFiles are copied from the top-level dashboard by go generate
+var sharedFiles = []string{
+	"schema.go",
+	"disaster_scenarios.schema.json",
+	"paths.go",
+	"sort.go",
+	"testresults.go",
+	"gzip.go",
+	"tags.go",
+	"etag.go",
+	"related.go",
+}
+
+func TestSharedCopiesInSync(t *testing.T) {
+	for _, name := range sharedFiles {
+		shared, err := os.ReadFile(filepath.Join("..", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		local, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(shared, local) {
+			t.Errorf("%s differs from ../%s; run go generate", name, name)
+		}
+	}
+}
+
+func TestBuildInfoCopyInSync(t *testing.T) {
+	shared, err := os.ReadFile(filepath.Join("..", "..", "connpool-monitor", "buildinfo.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := os.ReadFile("buildinfo.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(shared, local) {
+		t.Error("buildinfo.go differs from the connpool-monitor one; run go generate")
+	}
+}
+
+func TestValidateScenariosCatalog(t *testing.T) {
+	data, err := os.ReadFile("../../testing/on-prem/disaster_scenarios/disaster_scenarios.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateScenariosJSON(data); err != nil {
+		t.Errorf("shipped catalog fails validation: %v", err)
+	}
+}
+
+func TestDefaultScenariosInSync(t *testing.T) {
+	catalog, err := os.ReadFile("../../testing/on-prem/disaster_scenarios/disaster_scenarios.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(catalog, defaultScenarios) {
+		t.Error("default_scenarios.json differs from the testing catalog; run go generate")
+	}
+}
+
+func TestLoadScenariosEmbeddedFallback(t *testing.T) {
+	prevBase, prevData := baseDir, dataDir
+	prevScenarios, prevDiscarded, prevHash := scenarios, discardedScenarios, scenariosHash
+	t.Cleanup(func() {
+		baseDir, dataDir = prevBase, prevData
+		scenarios, discardedScenarios, scenariosHash = prevScenarios, prevDiscarded, prevHash
+	})
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	var embedded DisasterScenariosFile
+	if err := json.Unmarshal(defaultScenarios, &embedded); err != nil {
+		t.Fatal(err)
+	}
+
+	// No catalog next to baseDir, so the embedded one is used
+	root := t.TempDir()
+	baseDir, dataDir = filepath.Join(root, "on-prem"), ""
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) == 0 || len(scenarios) != len(embedded.Scenarios) {
+		t.Errorf("loaded %d scenarios, want the %d embedded ones", len(scenarios), len(embedded.Scenarios))
+	}
+	if !bytes.Contains(logs.Bytes(), []byte("using the embedded default")) {
+		t.Errorf("fallback not logged:\n%s", logs.String())
+	}
+
+	// An external catalog, when present, wins over the embedded one
+	var external map[string]interface{}
+	if err := json.Unmarshal(defaultScenarios, &external); err != nil {
+		t.Fatal(err)
+	}
+	external["scenarios"] = external["scenarios"].([]interface{})[:1]
+	data, err := json.Marshal(external)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "testing", "on-prem", "disaster_scenarios")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "disaster_scenarios.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	log.SetOutput(io.Discard)
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) != 1 {
+		t.Errorf("loaded %d scenarios, want the 1 from the external file", len(scenarios))
+	}
+}
+
+// setScenarios installs list as the loaded scenarios for the duration of
+// the test
+func setScenarios(t *testing.T, list []DisasterScenario) {
+	t.Helper()
+	hash, err := hashScenarios(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev, prevHash := scenarios, scenariosHash
+	scenarios, scenariosHash = list, hash
+	t.Cleanup(func() { scenarios, scenariosHash = prev, prevHash })
+}
+
+func TestHandleScenariosSortParam(t *testing.T) {
+	setScenarios(t, []DisasterScenario{
+		{Scenario: "b", RTOTarget: "1 hour"}, {Scenario: "a", RTOTarget: "5 minutes"}, {Scenario: "c", RTOTarget: "1 minute"},
+	})
+
+	rec := httptest.NewRecorder()
+	handleScenarios(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios?sort=rto", nil))
+	var got ScenarioResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Scenarios) != 3 || got.Scenarios[0].Scenario != "c" || got.Scenarios[2].Scenario != "b" {
+		t.Errorf("sort=rto returned %+v", got.Scenarios)
+	}
+
+	rec = httptest.NewRecorder()
+	handleScenarios(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios?sort=color", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown sort: status %d, want 400", rec.Code)
+	}
+}
+
+func TestLoadAndMergeTestResults(t *testing.T) {
+	dir := t.TempDir()
+	if results, err := loadTestResults(dir); err != nil || results != nil {
+		t.Fatalf("missing file: results %v, err %v", results, err)
+	}
+
+	data := `{
+		"Pod failure": {"lastRun": "2026-01-15T09:30:00Z", "lastResult": "passed", "durationSeconds": 184.2},
+		"Quorum loss": {"lastRun": "2026-01-10T12:00:00Z", "lastResult": "failed"},
+		"Retired scenario": {"lastRun": "2025-06-01T00:00:00Z", "lastResult": "passed"}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "test_results.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := loadTestResults(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := []DisasterScenario{{Scenario: "Pod failure"}, {Scenario: "Node failure"}, {Scenario: "Quorum loss"}}
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	mergeTestResults(list, results)
+
+	pod := list[0]
+	if pod.LastRun == nil || !pod.LastRun.Equal(time.Date(2026, 1, 15, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("pod failure last run = %v", pod.LastRun)
+	}
+	if pod.LastResult == nil || *pod.LastResult != "passed" || pod.DurationSeconds == nil || *pod.DurationSeconds != 184.2 {
+		t.Errorf("pod failure result/duration not merged: %+v", pod)
+	}
+
+	if node := list[1]; node.LastRun != nil || node.LastResult != nil || node.DurationSeconds != nil {
+		t.Errorf("scenario without a result gained fields: %+v", node)
+	}
+
+	quorum := list[2]
+	if quorum.LastResult == nil || *quorum.LastResult != "failed" || quorum.DurationSeconds != nil {
+		t.Errorf("quorum loss: result %v, duration %v", quorum.LastResult, quorum.DurationSeconds)
+	}
+
+	// Untested scenarios omit the fields entirely in the API response
+	encoded, err := json.Marshal(list[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(encoded), "last_run") {
+		t.Errorf("nil result fields encoded: %s", encoded)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "test_results.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTestResults(dir); err == nil {
+		t.Error("malformed test_results.json accepted")
+	}
+}
+
+func TestHandleScenariosTagFilter(t *testing.T) {
+	setScenarios(t, []DisasterScenario{
+		{Scenario: "Volume full", Tags: []string{"storage"}},
+		{Scenario: "Pod crash", Tags: []string{"kubernetes"}},
+		{Scenario: "EBS loss", Tags: []string{"kubernetes", "storage"}},
+	})
+
+	for _, tc := range []struct {
+		tag  string
+		want []string
+	}{
+		{"storage", []string{"EBS loss", "Volume full"}},
+		{" Storage ", []string{"EBS loss", "Volume full"}},
+		{"", []string{"EBS loss", "Pod crash", "Volume full"}},
+		{"network", []string{}},
+	} {
+		rec := httptest.NewRecorder()
+		handleScenarios(rec, httptest.NewRequest(http.MethodGet, "/api/scenarios?sort=name&tag="+url.QueryEscape(tc.tag), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("tag %q: status %d", tc.tag, rec.Code)
+		}
+		var resp struct {
+			Scenarios []DisasterScenario `json:"scenarios"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Scenarios == nil {
+			t.Errorf("tag %q: scenarios is null, want an array", tc.tag)
+		}
+		got := []string{}
+		for _, s := range resp.Scenarios {
+			got = append(got, s.Scenario)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("tag %q: got %q, want %q", tc.tag, got, tc.want)
+		}
+	}
+}
+
+// useCatalog points loadScenarios at a temporary ../testing/on-prem layout and
+// returns a function that writes the first n embedded default scenarios there
+func useCatalog(t *testing.T) (write func(n int)) {
+	t.Helper()
+	prevBase, prevData := baseDir, dataDir
+	prevScenarios, prevDiscarded, prevHash := scenarios, discardedScenarios, scenariosHash
+	t.Cleanup(func() {
+		baseDir, dataDir = prevBase, prevData
+		scenarios, discardedScenarios, scenariosHash = prevScenarios, prevDiscarded, prevHash
+	})
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	root := t.TempDir()
+	baseDir, dataDir = filepath.Join(root, "on-prem"), ""
+	var catalog map[string]interface{}
+	if err := json.Unmarshal(defaultScenarios, &catalog); err != nil {
+		t.Fatal(err)
+	}
+	all := catalog["scenarios"].([]interface{})
+
+	return func(n int) {
+		t.Helper()
+		catalog["scenarios"] = all[:n]
+		data, err := json.Marshal(catalog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := filepath.Join(root, "testing", "on-prem", "disaster_scenarios")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "disaster_scenarios.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScenariosETag(t *testing.T) {
+	write := useCatalog(t)
+	write(1)
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+	handler := withGzip(handleScenarios)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/scenarios", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status %d, ETag %q", first.Code, etag)
+	}
+	if rec := get(etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("status %d with a %d byte body for matching If-None-Match, want an empty 304", rec.Code, rec.Body.Len())
+	}
+	if rec := get(`W/"stale"`); rec.Code != http.StatusOK {
+		t.Errorf("status %d for a stale If-None-Match, want 200", rec.Code)
+	}
+
+	write(2)
+	if err := loadScenarios(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := get(etag)
+	if reloaded.Code != http.StatusOK {
+		t.Fatalf("status %d after reload, want 200 for the old ETag", reloaded.Code)
+	}
+	if reloaded.Header().Get("ETag") == etag {
+		t.Errorf("ETag %q unchanged after the catalog changed", etag)
+	}
+}
+
+func TestHandleRelated(t *testing.T) {
+	setScenarios(t, []DisasterScenario{
+		{Scenario: "Worker node failure", Related: []string{"Quorum loss", "Deleted scenario"}},
+		{Scenario: "Quorum loss", Related: []string{"Worker node failure"}},
+		{Scenario: "Backup failure"},
+	})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handleRelated(rec, httptest.NewRequest(http.MethodGet, "/api/scenario/related?"+query, nil))
+		return rec
+	}
+
+	rec := get("scenario=" + url.QueryEscape("Worker node failure"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	var resp RelatedResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Related) != 1 || resp.Related[0].Scenario != "Quorum loss" {
+		t.Fatalf("related = %+v, want the Quorum loss scenario only", resp.Related)
+	}
+	if !reflect.DeepEqual(resp.Related[0].Related, []string{"Worker node failure"}) {
+		t.Errorf("related entry is not the full scenario object: %+v", resp.Related[0])
+	}
+
+	if body := get("scenario=" + url.QueryEscape("Backup failure")).Body.String(); !strings.Contains(body, `"related":[]`) {
+		t.Errorf("scenario without related links: %s, want an empty array", body)
+	}
+
+	for query, want := range map[string]int{
+		"":                 http.StatusBadRequest,
+		"scenario=Missing": http.StatusNotFound,
+	} {
+		if code := get(query).Code; code != want {
+			t.Errorf("%s: status %d, want %d", query, code, want)
+		}
+	}
+}
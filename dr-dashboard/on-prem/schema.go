@@ -0,0 +1,209 @@
+package main
+
+// Disaster scenarios schema validation.
+//
+// This file, disaster_scenarios.schema.json and the other files marked as
+// shared are the canonical copies. eks/ and on-prem/ are separate modules and
+// carry byte-identical copies, refreshed with `go generate` in those
+// directories (or `make sync-shared`); their tests fail when a copy drifts
+// from this one.
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed disaster_scenarios.schema.json
+var scenariosSchemaJSON []byte
+
+// jsonSchema is the subset of JSON Schema used by
+// disaster_scenarios.schema.json: object/array nesting, required fields,
+// property types and string minLength
+type jsonSchema struct {
+	Type       schemaTypes            `json:"type"`
+	Required   []string               `json:"required"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Items      *jsonSchema            `json:"items"`
+	MinLength  int                    `json:"minLength"`
+}
+
+// schemaTypes accepts both "type": "string" and "type": ["string", "null"]
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// validateScenariosJSON checks a disaster scenarios file against the
+// embedded schema. Errors name the offending scenario and field so catalog
+// typos are caught at startup instead of rendering as blanks in the UI.
+func validateScenariosJSON(data []byte) error {
+	var schema jsonSchema
+	if err := json.Unmarshal(scenariosSchemaJSON, &schema); err != nil {
+		return fmt.Errorf("invalid embedded schema: %w", err)
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, field := range schema.Required {
+		if _, ok := doc[field]; !ok {
+			errs = append(errs, fmt.Errorf("missing required top-level field %q", field))
+		}
+	}
+
+	// Sorted for deterministic error output
+	lists := make([]string, 0, len(schema.Properties))
+	for listName := range schema.Properties {
+		lists = append(lists, listName)
+	}
+	sort.Strings(lists)
+
+	for _, listName := range lists {
+		listSchema := schema.Properties[listName]
+		raw, ok := doc[listName]
+		if !ok || listSchema.Items == nil {
+			continue
+		}
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			errs = append(errs, fmt.Errorf("%s: expected an array of objects: %w", listName, err))
+			continue
+		}
+		for i, item := range items {
+			name := fmt.Sprintf("#%d", i+1)
+			var scenarioName string
+			if json.Unmarshal(item["scenario"], &scenarioName) == nil && scenarioName != "" {
+				name = fmt.Sprintf("#%d %q", i+1, scenarioName)
+			}
+			for _, err := range validateSchemaObject(item, listSchema.Items) {
+				errs = append(errs, fmt.Errorf("%s %s: %w", listName, name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateSchemaObject(obj map[string]json.RawMessage, schema *jsonSchema) []error {
+	var errs []error
+	for _, field := range schema.Required {
+		if _, ok := obj[field]; !ok {
+			errs = append(errs, fmt.Errorf("missing required field %q", field))
+		}
+	}
+
+	// Sorted for deterministic error output
+	fields := make([]string, 0, len(schema.Properties))
+	for field := range schema.Properties {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		raw, ok := obj[field]
+		if !ok {
+			continue
+		}
+		errs = append(errs, validateSchemaValue(fmt.Sprintf("field %q", field), raw, schema.Properties[field])...)
+	}
+	return errs
+}
+
+// validateSchemaValue checks one value against its schema, descending into
+// array items and nested objects; what names the value in errors
+func validateSchemaValue(what string, raw json.RawMessage, schema *jsonSchema) []error {
+	actual := jsonTypeOf(raw)
+	if len(schema.Type) > 0 && !schemaAllowsType(schema.Type, actual) {
+		return []error{fmt.Errorf("%s must be %s, got %s", what, strings.Join(schema.Type, " or "), actual)}
+	}
+
+	switch actual {
+	case "string":
+		if schema.MinLength > 0 {
+			var s string
+			json.Unmarshal(raw, &s)
+			if len(strings.TrimSpace(s)) < schema.MinLength {
+				return []error{fmt.Errorf("%s must not be empty", what)}
+			}
+		}
+	case "array":
+		if schema.Items == nil {
+			return nil
+		}
+		var items []json.RawMessage
+		json.Unmarshal(raw, &items)
+		var errs []error
+		for i, item := range items {
+			errs = append(errs, validateSchemaValue(fmt.Sprintf("%s item %d", what, i+1), item, schema.Items)...)
+		}
+		return errs
+	case "object":
+		if len(schema.Required) == 0 && len(schema.Properties) == 0 {
+			return nil
+		}
+		var obj map[string]json.RawMessage
+		json.Unmarshal(raw, &obj)
+		var errs []error
+		for _, err := range validateSchemaObject(obj, schema) {
+			errs = append(errs, fmt.Errorf("%s: %w", what, err))
+		}
+		return errs
+	}
+	return nil
+}
+
+// jsonTypeOf returns the JSON Schema type name of a raw JSON value
+func jsonTypeOf(raw json.RawMessage) string {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return "invalid"
+	}
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return "invalid"
+}
+
+func schemaAllowsType(allowed schemaTypes, actual string) bool {
+	for _, t := range allowed {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+// Shared with eks/ and on-prem/, which carry byte-identical copies; see
+// schema.go.
+
+import "strings"
+
+// componentTags maps keywords in affected_components to the tags derived for
+// scenarios that don't list tags explicitly
+var componentTags = []struct {
+	tag      string
+	keywords []string
+}{
+	{"backup", []string{"backup", "minio", "s3"}},
+	{"data-integrity", []string{"data integrity", "production data", "application data", "schema"}},
+	{"kubernetes", []string{"k8s", "kubernetes", "statefulset", "operator", "pod", "node", "eks"}},
+	{"monitoring", []string{"monitoring", "alerting", "metrics", "observability", "cloudwatch"}},
+	{"network", []string{"network", "dns", "route53", "vpc", "ingress", "service mesh", "connectivity"}},
+	{"performance", []string{"cpu", "memory", "i/o", "performance", "query execution"}},
+	{"proxy", []string{"haproxy", "proxysql", "connection pool"}},
+	{"replication", []string{"replication", "binlog"}},
+	{"security", []string{"iam", "credential", "secret", "encryption", "kms", "users", "audit"}},
+	{"storage", []string{"storage", "pvc", "persistentvolume", "ebs", "volume", "data files", "tablespace"}},
+}
+
+// deriveTags returns the tags whose keywords appear in components, sorted
+func deriveTags(components string) []string {
+	text := strings.ToLower(components)
+	var tags []string
+	for _, ct := range componentTags {
+		for _, keyword := range ct.keywords {
+			if strings.Contains(text, keyword) {
+				tags = append(tags, ct.tag)
+				break
+			}
+		}
+	}
+	return tags
+}
+
+// applyTags lowercases explicit tags and derives tags from
+// affected_components for scenarios without any
+func applyTags(list []DisasterScenario) {
+	for i := range list {
+		if len(list[i].Tags) == 0 {
+			list[i].Tags = deriveTags(list[i].AffectedComponents)
+			continue
+		}
+		for j, tag := range list[i].Tags {
+			list[i].Tags[j] = strings.ToLower(strings.TrimSpace(tag))
+		}
+	}
+}
+
+// filterByTag returns the scenarios carrying tag, ignoring case. An empty
+// tag returns list unchanged.
+func filterByTag(list []DisasterScenario, tag string) []DisasterScenario {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return list
+	}
+
+	filtered := []DisasterScenario{}
+	for _, s := range list {
+		for _, t := range s.Tags {
+			if t == tag {
+				filtered = append(filtered, s)
+				break
+			}
+		}
+	}
+	return filtered
+}
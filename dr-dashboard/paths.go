@@ -0,0 +1,50 @@
+package main
+
+// Shared with eks/ and on-prem/, which carry byte-identical copies; see
+// schema.go.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveContainedPath joins filename onto dir and verifies the result
+// stays directly inside dir. Separators (either slash), encoded sequences and
+// parent references are rejected up front; the cleaned absolute path and its
+// symlink-resolved target must both remain under dir.
+func resolveContainedPath(dir, filename string) (string, error) {
+	if strings.ContainsAny(filename, `/\%`) || strings.Contains(filename, "..") || filepath.IsAbs(filename) {
+		return "", fmt.Errorf("filename contains path separators or traversal sequences")
+	}
+
+	absDir, err := filepath.Abs(filepath.Clean(dir))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	absPath := filepath.Join(absDir, filepath.Clean(filename))
+	if filepath.Dir(absPath) != absDir {
+		return "", fmt.Errorf("resolved path %s escapes %s", absPath, absDir)
+	}
+
+	// Follow symlinks so a link placed inside dir cannot point outside it.
+	// A missing file is left for the caller to report as not found.
+	realPath, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return absPath, nil
+		}
+		return "", fmt.Errorf("failed to resolve %s: %w", absPath, err)
+	}
+	realDir, err := filepath.EvalSymlinks(absDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", absDir, err)
+	}
+	if !strings.HasPrefix(realPath, realDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("resolved path %s escapes %s", realPath, realDir)
+	}
+
+	return realPath, nil
+}
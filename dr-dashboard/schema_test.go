@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// validScenario returns a scenario with every required field set
+func validScenario(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"scenario":                name,
+		"primary_recovery_method": "Restart the pod",
+		"alternate_fallback":      "Restore from backup",
+		"detection_signals":       "Pod not ready",
+		"rto_target":              "15 minutes",
+		"rpo_target":              "0",
+		"mttr_expected":           "10 minutes",
+		"expected_data_loss":      "None",
+		"likelihood":              "Medium",
+		"business_impact":         "High",
+		"affected_components":     "PXC",
+		"test_enabled":            true,
+		"tags":                    []interface{}{"pxc"},
+	}
+}
+
+func catalogJSON(t *testing.T, doc map[string]interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestValidateScenariosJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		mutate func(doc map[string]interface{}, s map[string]interface{})
+		want   []string
+	}{
+		{
+			name:   "valid",
+			mutate: func(doc, s map[string]interface{}) {},
+		},
+		{
+			name:   "missing required field",
+			mutate: func(doc, s map[string]interface{}) { delete(s, "rto_target") },
+			want:   []string{`scenarios #1 "Pod crash": missing required field "rto_target"`},
+		},
+		{
+			name:   "wrong type",
+			mutate: func(doc, s map[string]interface{}) { s["test_enabled"] = "yes" },
+			want:   []string{`field "test_enabled" must be boolean, got string`},
+		},
+		{
+			name:   "empty string",
+			mutate: func(doc, s map[string]interface{}) { s["rpo_target"] = "  " },
+			want:   []string{`field "rpo_target" must not be empty`},
+		},
+		{
+			name:   "empty tag",
+			mutate: func(doc, s map[string]interface{}) { s["tags"] = []interface{}{"pxc", ""} },
+			want:   []string{`field "tags" item 2 must not be empty`},
+		},
+		{
+			name:   "non-string related entry",
+			mutate: func(doc, s map[string]interface{}) { s["related"] = []interface{}{"Node loss", 7} },
+			want:   []string{`field "related" item 2 must be string, got integer`},
+		},
+		{
+			name:   "tags not an array",
+			mutate: func(doc, s map[string]interface{}) { s["tags"] = "pxc" },
+			want:   []string{`field "tags" must be array, got string`},
+		},
+		{
+			name: "discarded scenario without reason",
+			mutate: func(doc, s map[string]interface{}) {
+				doc["discarded_scenarios"] = []interface{}{map[string]interface{}{"scenario": "Meteor strike"}}
+			},
+			want: []string{`discarded_scenarios #1 "Meteor strike": missing required field "reason"`},
+		},
+		{
+			name:   "missing scenarios list",
+			mutate: func(doc, s map[string]interface{}) { delete(doc, "scenarios") },
+			want:   []string{`missing required top-level field "scenarios"`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := validScenario("Pod crash")
+			doc := map[string]interface{}{"scenarios": []interface{}{s}}
+			tc.mutate(doc, s)
+
+			err := validateScenariosJSON(catalogJSON(t, doc))
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q, got none", tc.want)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateScenariosJSONReportsEveryScenario(t *testing.T) {
+	first, second := validScenario("Pod crash"), validScenario("Node loss")
+	delete(first, "likelihood")
+	second["tags"] = []interface{}{""}
+	doc := map[string]interface{}{"scenarios": []interface{}{first, second}}
+
+	err := validateScenariosJSON(catalogJSON(t, doc))
+	if err == nil {
+		t.Fatal("expected errors, got none")
+	}
+	for _, want := range []string{
+		`scenarios #1 "Pod crash": missing required field "likelihood"`,
+		`scenarios #2 "Node loss": field "tags" item 1 must not be empty`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestShippedCatalogsValidate(t *testing.T) {
+	for _, path := range []string{
+		"../testing/eks/disaster_scenarios/disaster_scenarios.json",
+		"../testing/on-prem/disaster_scenarios/disaster_scenarios.json",
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := validateScenariosJSON(data); err != nil {
+			t.Errorf("%s fails validation: %v", path, err)
+		}
+	}
+}
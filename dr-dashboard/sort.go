@@ -0,0 +1,89 @@
+package main
+
+// Shared with eks/ and on-prem/, which carry byte-identical copies; see
+// schema.go.
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// severityRank orders business impact and likelihood from most to least severe
+var severityRank = map[string]int{"critical": 0, "high": 1, "medium": 2, "low": 3}
+
+var rtoPattern = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*(second|minute|hour|day)?`)
+
+// sortScenarios returns a copy of list ordered by mode: "name" (the
+// default), "impact", "likelihood" or "rto". Ties fall back to the scenario
+// name so the order doesn't shift when the catalog file is edited.
+func sortScenarios(list []DisasterScenario, mode string) ([]DisasterScenario, error) {
+	var less func(a, b DisasterScenario) bool
+	switch mode {
+	case "", "name":
+		less = func(a, b DisasterScenario) bool { return false }
+	case "impact":
+		less = func(a, b DisasterScenario) bool {
+			return rankOf(a.BusinessImpact) < rankOf(b.BusinessImpact)
+		}
+	case "likelihood":
+		less = func(a, b DisasterScenario) bool {
+			return rankOf(a.Likelihood) < rankOf(b.Likelihood)
+		}
+	case "rto":
+		less = func(a, b DisasterScenario) bool {
+			return parseRTO(a.RTOTarget) < parseRTO(b.RTOTarget)
+		}
+	default:
+		return nil, fmt.Errorf("unknown sort %q (expected name, impact, likelihood or rto)", mode)
+	}
+
+	sorted := make([]DisasterScenario, len(list))
+	copy(sorted, list)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if less(sorted[i], sorted[j]) {
+			return true
+		}
+		if less(sorted[j], sorted[i]) {
+			return false
+		}
+		return strings.ToLower(sorted[i].Scenario) < strings.ToLower(sorted[j].Scenario)
+	})
+	return sorted, nil
+}
+
+// rankOf maps a severity label to its rank; unknown labels sort last
+func rankOf(label string) int {
+	if rank, ok := severityRank[strings.ToLower(strings.TrimSpace(label))]; ok {
+		return rank
+	}
+	return len(severityRank)
+}
+
+// parseRTO converts targets such as "15 minutes" or "2 hours" to a duration.
+// A bare number is taken as minutes; unparseable targets ("N/A ...") sort last.
+func parseRTO(rto string) time.Duration {
+	m := rtoPattern.FindStringSubmatch(strings.ToLower(rto))
+	if m == nil {
+		return time.Duration(math.MaxInt64)
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return time.Duration(math.MaxInt64)
+	}
+
+	unit := time.Minute
+	switch m[2] {
+	case "second":
+		unit = time.Second
+	case "hour":
+		unit = time.Hour
+	case "day":
+		unit = 24 * time.Hour
+	}
+	return time.Duration(value * float64(unit))
+}
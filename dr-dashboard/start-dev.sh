@@ -17,4 +17,4 @@ echo "🚀 Starting server on port $PORT..."
 echo "📍 Open http://localhost:$PORT in your browser"
 echo ""
 
-PORT=$PORT go run main.go
+PORT=$PORT go run .
@@ -33,7 +33,7 @@ echo "📦 Downloading dependencies..."
 go mod download
 
 echo "🔨 Building application..."
-go build -o dr-dashboard-bin main.go
+go build -o dr-dashboard-bin .
 
 echo "✅ Build complete!"
 echo "🚀 Starting server on port $PORT..."